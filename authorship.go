@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+	protolib "google.golang.org/protobuf/proto"
+)
+
+// PayloadHashClaim is the token claim holding the hash of the request body,
+// so that the signature of the token covers the submitted events.
+const PayloadHashClaim = "payload_hash"
+
+// EventsHashClaim is the token claim holding the hash of the digests of the
+// submitted events, see EventDigests. Unlike the payload hash, it still
+// covers the other events of a batch once one of them is erased.
+const EventsHashClaim = "events_hash"
+
+// PayloadHash returns the unpadded base64url SHA-256 of payload.
+func PayloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// EventsHash returns the unpadded base64url SHA-256 of the concatenated
+// digests.
+func EventsHash(digests [][]byte) string {
+	return PayloadHash(bytes.Join(digests, nil))
+}
+
+// eventsField is the field number of the events of an Events payload.
+var eventsField = (&proto.Events{}).ProtoReflect().Descriptor().Fields().ByName("events").Number()
+
+// splitEvents returns the encoding of each event of an Events payload, as
+// the client encoded it.
+func splitEvents(payload []byte) ([][]byte, error) {
+	var events [][]byte
+
+	_, err := walkEvents(payload, func(event []byte) ([]byte, bool) {
+		events = append(events, event)
+
+		return nil, false
+	})
+
+	return events, err
+}
+
+// walkEvents calls fn with the encoding of each event of an Events payload
+// and returns the payload with the events fn replaces, the other fields are
+// kept as they are.
+func walkEvents(payload []byte, fn func(event []byte) ([]byte, bool)) ([]byte, error) {
+	var walked []byte
+
+	for len(payload) > 0 {
+		number, kind, n := protowire.ConsumeTag(payload)
+		if n < 0 {
+			return nil, fmt.Errorf("payload: %w", protowire.ParseError(n))
+		}
+
+		if number == eventsField && kind == protowire.BytesType {
+			event, m := protowire.ConsumeBytes(payload[n:])
+			if m < 0 {
+				return nil, fmt.Errorf("payload: %w", protowire.ParseError(m))
+			}
+
+			if replacement, replace := fn(event); replace {
+				walked = protowire.AppendTag(walked, number, kind)
+				walked = protowire.AppendBytes(walked, replacement)
+				payload = payload[n+m:]
+
+				continue
+			}
+
+			n += m
+		} else {
+			m := protowire.ConsumeFieldValue(number, kind, payload[n:])
+			if m < 0 {
+				return nil, fmt.Errorf("payload: %w", protowire.ParseError(m))
+			}
+
+			n += m
+		}
+
+		walked = append(walked, payload[:n]...)
+		payload = payload[n:]
+	}
+
+	return walked, nil
+}
+
+// EventDigests returns the SHA-256 of each event of an Events payload.
+func EventDigests(payload []byte) ([][]byte, error) {
+	events, err := splitEvents(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([][]byte, len(events))
+
+	for i, event := range events {
+		sum := sha256.Sum256(event)
+		digests[i] = sum[:]
+	}
+
+	return digests, nil
+}
+
+// redactPayload replaces the event at index of an Events payload by
+// replacement.
+func redactPayload(payload []byte, index int, replacement *proto.Event) ([]byte, error) {
+	data, err := protolib.Marshal(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling event to proto: %w", err)
+	}
+
+	var i int
+
+	return walkEvents(payload, func(event []byte) ([]byte, bool) {
+		i++
+
+		return data, i-1 == index
+	})
+}
+
+// Authorship is the proof that an event was submitted by the holder of a
+// device key.
+type Authorship struct {
+	Ts            int64
+	SourceActorID int64
+	PublicKey     []byte
+}
+
+// VerifyAuthorship checks, long after the token expired, that the event at ts
+// is the one its batch token signed.
+func VerifyAuthorship(store EventStore, ts int64) (Authorship, error) {
+	authorship := Authorship{Ts: ts}
+
+	records, err := store.GetEvents(ts-1, EventRecordStatusAll)
+	if err != nil {
+		return authorship, fmt.Errorf("get events: %w", err)
+	}
+
+	if len(records) == 0 || records[0].Event.Ts != ts {
+		return authorship, fmt.Errorf("event %d not found", ts)
+	}
+
+	authorship.SourceActorID = records[0].SourceActorID
+
+	batch, index, err := store.GetBatch(ts)
+	if err != nil {
+		return authorship, fmt.Errorf("event %d has no batch: %w", ts, err)
+	}
+
+	authorship.PublicKey = batch.PublicKey
+
+	publicKey, claims, err := parseToken(batch.Token, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return authorship, fmt.Errorf("token: %w", err)
+	}
+
+	if !bytes.Equal(publicKeyBytes(publicKey), batch.PublicKey) {
+		return authorship, fmt.Errorf("token is signed by another public key")
+	}
+
+	// The payload of a batch redacted by an erasure no longer matches the
+	// payload hash, the digests of its events still match the events hash.
+	if batch.Digests == nil {
+		payloadHash, _ := claims[PayloadHashClaim].(string)
+		if payloadHash != PayloadHash(batch.Payload) {
+			return authorship, fmt.Errorf("token does not sign the payload")
+		}
+	} else {
+		eventsHash, _ := claims[EventsHashClaim].(string)
+		if eventsHash != EventsHash(batch.Digests) {
+			return authorship, fmt.Errorf("token does not sign the events of the redacted payload")
+		}
+	}
+
+	events, err := splitEvents(batch.Payload)
+	if err != nil {
+		return authorship, err
+	}
+
+	if index < 0 || index >= len(events) {
+		return authorship, fmt.Errorf("event index %d out of a payload of %d events", index, len(events))
+	}
+
+	if batch.Digests != nil {
+		digest := sha256.Sum256(events[index])
+		if index >= len(batch.Digests) || !bytes.Equal(digest[:], batch.Digests[index]) {
+			return authorship, fmt.Errorf("event was erased from the payload")
+		}
+	}
+
+	// The ts is set by the server once the payload is signed.
+	signed := &proto.Event{}
+
+	err = protolib.Unmarshal(events[index], signed)
+	if err != nil {
+		return authorship, fmt.Errorf("payload: proto unmarshall: %w", err)
+	}
+
+	signed.Ts = ts
+
+	if !protolib.Equal(signed, records[0].Event) {
+		return authorship, fmt.Errorf("event differs from the signed payload")
+	}
+
+	return authorship, nil
+}
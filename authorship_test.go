@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/jwk"
+	protolib "google.golang.org/protobuf/proto"
+)
+
+// signPayload returns the token a client signs with key to submit payload.
+func signPayload(t *testing.T, key *ecdsa.PrivateKey, payload []byte) string {
+	t.Helper()
+
+	jwkKey, err := jwk.New(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(30 * time.Second).Unix(),
+		"iss":            "self",
+		"aud":            "thekeeper",
+		PayloadHashClaim: PayloadHash(payload),
+		EventsHashClaim:  eventsHash(t, payload),
+	})
+	token.Header["jwk"] = jwkKey
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenString
+}
+
+// eventsHash returns the events_hash claim of payload.
+func eventsHash(t *testing.T, payload []byte) string {
+	t.Helper()
+
+	digests, err := EventDigests(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return EventsHash(digests)
+}
+
+func submitBatch(t *testing.T, store Store, key *ecdsa.PrivateKey, events ...*proto.Event) []RunEventResult {
+	t.Helper()
+
+	payload, err := protolib.Marshal(&proto.Events{Events: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validation := NewValidation()
+
+	actorID, batch, err := authBatch(store, validation, signPayload(t, key, payload), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var request proto.Events
+
+	err = protolib.Unmarshal(payload, &request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := InsertAndCheckEvents(store, validation, -1, actorID, batch, request.Events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return results
+}
+
+func TestVerifyAuthorship(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) Store{
+		"sqlite": func(t *testing.T) Store { return openTestStore(t) },
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := submitBatch(t, store, key, seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art"))
+
+			for _, result := range results {
+				authorship, err := VerifyAuthorship(store, result.Ts)
+				if err != nil {
+					t.Fatalf("event %d: %v", result.Ts, err)
+				}
+
+				if !bytes.Equal(authorship.PublicKey, publicKeyBytes(key.PublicKey)) {
+					t.Errorf("event %d: public key %x, want %x", result.Ts, authorship.PublicKey, publicKeyBytes(key.PublicKey))
+				}
+			}
+
+			orgaResults, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{edition("2025")})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = VerifyAuthorship(store, orgaResults[0].Ts)
+			if err == nil {
+				t.Error("event without batch verified")
+			}
+		})
+	}
+}
+
+func TestAuthBatchRejectsUnsignedPayload(t *testing.T) {
+	store := openTestStore(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := protolib.Marshal(&proto.Events{Events: []*proto.Event{seedActor("art-coffee")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signPayload(t, key, payload)
+
+	tampered, err := protolib.Marshal(&proto.Events{Events: []*proto.Event{seedActor("tea-grumpy")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = authBatch(store, NewValidation(), token, tampered)
+	if err == nil {
+		t.Fatal("tampered payload accepted")
+	}
+}
+
+func TestVerifyAuthorshipDetectsTamperedEvent(t *testing.T) {
+	store := openTestStore(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := submitBatch(t, store, key, seedActor("art-coffee"))
+
+	data, err := protolib.Marshal(&proto.Event{
+		Ts:  results[0].Ts,
+		Msg: &proto.Event_SeedActor{SeedActor: &proto.EventSeedActor{Handle: "tea-grumpy"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.db.Exec(`UPDATE events SET data=? WHERE ts=?`, data, results[0].Ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyAuthorship(store, results[0].Ts)
+	if err == nil {
+		t.Fatal("tampered event verified")
+	}
+}
+
+func TestVerifyAuthorshipAfterExportImport(t *testing.T) {
+	store := openTestStore(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := submitBatch(t, store, key, seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art"))
+
+	var exported bytes.Buffer
+
+	err = Export(store.db, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported := openTestStore(t)
+
+	err = Import(imported.db, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range results {
+		_, err = VerifyAuthorship(imported, result.Ts)
+		if err != nil {
+			t.Errorf("event %d: %v", result.Ts, err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// copyDB copies the main database of src into dst with the SQLite online
+// backup API. Writers of src are not blocked while the copy runs.
+func copyDB(dst, src *sqlx.DB) error {
+	ctx := context.Background()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("source conn: %w", err)
+	}
+
+	defer srcConn.Close()
+
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("destination conn: %w", err)
+	}
+
+	defer dstConn.Close()
+
+	return dstConn.Raw(func(dstDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			dstSQLite, ok := dstDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("destination is not a sqlite connection")
+			}
+
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("source is not a sqlite connection")
+			}
+
+			backup, err := dstSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("backup init: %w", err)
+			}
+
+			for {
+				done, err := backup.Step(256)
+				if err != nil {
+					backup.Finish()
+
+					return fmt.Errorf("backup step: %w", err)
+				}
+
+				if done {
+					break
+				}
+			}
+
+			err = backup.Finish()
+			if err != nil {
+				return fmt.Errorf("backup finish: %w", err)
+			}
+
+			return nil
+		})
+	})
+}
+
+// Backup writes an online copy of db to out, which must not exist.
+func Backup(db *sqlx.DB, out string) error {
+	_, err := os.Stat(out)
+	if err == nil {
+		return fmt.Errorf("%s already exists", out)
+	}
+
+	dst, err := OpenDB(out)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", out, err)
+	}
+
+	defer dst.Close()
+
+	err = copyDB(dst, db)
+	if err != nil {
+		return fmt.Errorf("copy: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyReplay replays the whole log of store and fails when an event would
+// change status.
+func VerifyReplay(store EventStore) error {
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		return fmt.Errorf("get events: %w", err)
+	}
+
+	tss := map[int64]bool{}
+	for _, record := range records {
+		tss[record.Event.Ts] = true
+	}
+
+	results, err := Run(store, tss)
+	if err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+
+	var changed int
+
+	for i, result := range results {
+		if result.Status != records[i].Status {
+			changed++
+		}
+	}
+
+	if changed > 0 {
+		return fmt.Errorf("%d of %d events changed status on replay", changed, len(records))
+	}
+
+	return nil
+}
+
+// Restore replaces the content of db with the backup at in. The backup is
+// first copied aside, migrated and checked with a full replay, db is only
+// overwritten once it passed.
+func Restore(db *sqlx.DB, in string) error {
+	_, err := os.Stat(in)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", in, err)
+	}
+
+	src, err := OpenDB(in)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", in, err)
+	}
+
+	defer src.Close()
+
+	dir, err := os.MkdirTemp("", "thekeeper-restore")
+	if err != nil {
+		return fmt.Errorf("temp dir: %w", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	candidate, err := OpenDB(filepath.Join(dir, "restore.db"))
+	if err != nil {
+		return fmt.Errorf("open candidate: %w", err)
+	}
+
+	defer candidate.Close()
+
+	err = copyDB(candidate, src)
+	if err != nil {
+		return fmt.Errorf("copy backup: %w", err)
+	}
+
+	_, err = Migrate(candidate, false)
+	if err != nil {
+		return fmt.Errorf("migrate backup: %w", err)
+	}
+
+	// The replay opens the sealed medical fields, a backup holding some
+	// cannot be checked without the medical key.
+	err = VerifyReplay(NewSQLiteStore(candidate))
+	if errors.Is(err, ErrMedicalKey) {
+		return fmt.Errorf("verify backup: the backup holds sealed medical fields: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("verify backup: %w", err)
+	}
+
+	err = copyDB(db, candidate)
+	if err != nil {
+		return fmt.Errorf("copy to database: %w", err)
+	}
+
+	return nil
+}
+
+// BackupSchedule configures the periodic backups of a running server.
+type BackupSchedule struct {
+	Dir      string
+	Interval time.Duration
+	Keep     int
+}
+
+const backupPrefix = "thekeeper-"
+
+// Run writes a backup of db to the schedule directory every interval and
+// removes the oldest backups beyond Keep. It never returns.
+func (b BackupSchedule) Run(db *sqlx.DB) {
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := b.backup(db, time.Now().UTC())
+		if err != nil {
+			log.Printf("periodic backup: %v", err)
+		}
+	}
+}
+
+func (b BackupSchedule) backup(db *sqlx.DB, now time.Time) error {
+	out := filepath.Join(b.Dir, backupPrefix+now.Format("20060102T150405Z")+".db")
+
+	err := Backup(db, out)
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+
+	log.Printf("backup written to %s", out)
+
+	if b.Keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(b.Dir)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	var backups []string
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, ".db") {
+			backups = append(backups, name)
+		}
+	}
+
+	// Names embed the UTC time, the lexical order is the chronological one.
+	sort.Strings(backups)
+
+	for len(backups) > b.Keep {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			err = os.Remove(filepath.Join(b.Dir, backups[0]+suffix))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove: %w", err)
+			}
+		}
+
+		backups = backups[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBackupRestore(t *testing.T) {
+	store := newTestStore(t)
+
+	results, err := InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		seedActor("again"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "backup.db")
+
+	err = Backup(store.db, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Backup(store.db, out)
+	if err == nil {
+		t.Fatal("expected an error when the backup file exists")
+	}
+
+	restored := openTestStore(t)
+
+	err = Restore(restored.db, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(storedStatuses(t, store), storedStatuses(t, restored)); diff != "" {
+		t.Fatalf("restored events differ (-original +restored):\n%s", diff)
+	}
+
+	// A backup whose statuses do not match a replay is refused.
+	_, err = store.db.Exec(`UPDATE events SET status = ? WHERE ts = ?`, EventRecordStatusAccepted, results[2].Ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := filepath.Join(t.TempDir(), "tampered.db")
+
+	err = Backup(store.db, tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Restore(restored.db, tampered)
+	if err == nil {
+		t.Fatal("expected the tampered backup to be refused")
+	}
+
+	if status := storedStatuses(t, restored)[results[2].Ts]; status != EventRecordStatusRejected {
+		t.Fatalf("refused restore modified the database: event has status %v", status)
+	}
+}
+
+func TestRestoreSealedBackup(t *testing.T) {
+	cipher, err := NewFieldCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldCipher = cipher
+	t.Cleanup(func() { fieldCipher = nil })
+
+	store := newTestStore(t)
+
+	_, err = InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "backup.db")
+
+	err = Backup(store.db, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := openTestStore(t)
+
+	// Without the medical key the replay cannot check the backup.
+	fieldCipher = nil
+
+	err = Restore(restored.db, out)
+	if !errors.Is(err, ErrMedicalKey) {
+		t.Fatalf("restore without the medical key: %v", err)
+	}
+
+	if statuses := storedStatuses(t, restored); len(statuses) != 0 {
+		t.Fatalf("refused restore modified the database: %v", statuses)
+	}
+
+	fieldCipher = cipher
+
+	err = Restore(restored.db, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(storedStatuses(t, store), storedStatuses(t, restored)); diff != "" {
+		t.Fatalf("restored events differ (-original +restored):\n%s", diff)
+	}
+}
+
+func TestBackupScheduleRetention(t *testing.T) {
+	store := newTestStore(t)
+
+	schedule := BackupSchedule{
+		Dir:      t.TempDir(),
+		Interval: time.Hour,
+		Keep:     2,
+	}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		err := schedule.backup(store.db, now.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(schedule.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	want := []string{"thekeeper-20261018T130000Z.db", "thekeeper-20261018T140000Z.db"}
+
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Fatalf("kept backups (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a hybrid logical clock handing out event timestamps. A timestamp
+// is the wall clock in milliseconds times 1000 plus a logical counter, so the
+// values stay comparable with the ones already stored in the events table.
+// The counter takes over when the wall clock stalls or goes backwards, which
+// keeps every timestamp strictly greater than the previous one.
+type Clock struct {
+	mu   sync.Mutex
+	last int64
+	now  func() time.Time
+}
+
+func NewClock() *Clock {
+	return &Clock{
+		now: time.Now,
+	}
+}
+
+// Observe moves the clock past ts, typically the greatest ts already stored.
+func (c *Clock) Observe(ts int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ts > c.last {
+		c.last = ts
+	}
+}
+
+// Next returns n consecutive timestamps, each greater than any timestamp
+// previously returned or observed.
+func (c *Clock) Next(n int) []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ts := c.now().UnixMilli() * 1000
+	if ts <= c.last {
+		ts = c.last + 1
+	}
+
+	tss := make([]int64, n)
+	for i := range tss {
+		tss[i] = ts + int64(i)
+	}
+
+	if n > 0 {
+		c.last = tss[n-1]
+	}
+
+	return tss
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+func TestClockMonotonicWhenWallClockGoesBackwards(t *testing.T) {
+	wall := time.UnixMilli(1_700_000_000_000)
+
+	clock := NewClock()
+	clock.now = func() time.Time { return wall }
+
+	first := clock.Next(3)
+
+	wall = wall.Add(-time.Hour)
+
+	second := clock.Next(2)
+
+	all := append(first, second...)
+	for i := 1; i < len(all); i++ {
+		if all[i] <= all[i-1] {
+			t.Fatalf("ts %d is not greater than %d: %v", all[i], all[i-1], all)
+		}
+	}
+
+	if first[0] != wall.Add(time.Hour).UnixMilli()*1000 {
+		t.Fatalf("ts %d does not use the millisecond encoding", first[0])
+	}
+}
+
+func TestInsertEventsUniqueAcrossConcurrentBatchesAndRestarts(t *testing.T) {
+	store := newTestStore(t)
+
+	previous := eventClock
+	t.Cleanup(func() { eventClock = previous })
+
+	var wg sync.WaitGroup
+
+	tssCh := make(chan []int64, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			tss, err := store.InsertEvents(1, nil, []*proto.Event{seedActor("a"), seedActor("b"), seedActor("c")})
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			tssCh <- tss
+		}()
+	}
+
+	wg.Wait()
+	close(tssCh)
+
+	seen := map[int64]bool{}
+	var maxTs int64
+
+	for tss := range tssCh {
+		for i, ts := range tss {
+			if seen[ts] {
+				t.Fatalf("ts %d allocated twice", ts)
+			}
+
+			if i > 0 && ts <= tss[i-1] {
+				t.Fatalf("batch not increasing: %v", tss)
+			}
+
+			seen[ts] = true
+			maxTs = max(maxTs, ts)
+		}
+	}
+
+	// A restarted server with a wall clock behind the stored events.
+	eventClock = NewClock()
+	eventClock.now = func() time.Time { return time.UnixMilli(0) }
+
+	tss, err := store.InsertEvents(1, nil, []*proto.Event{seedActor("d")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tss[0] <= maxTs {
+		t.Fatalf("ts %d allocated after restart is not greater than %d", tss[0], maxTs)
+	}
+}
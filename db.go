@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	cryptorand "crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"time"
 
 	"github.com/ebenaum/thekeeper/proto"
@@ -13,40 +16,175 @@ import (
 	protolib "google.golang.org/protobuf/proto"
 )
 
-type ActorSpace string
+// OpenDB opens the SQLite database at path. Secure delete overwrites the
+// content SQLite frees with zeros, erased data does not linger in the file.
+func OpenDB(path string) (*sqlx.DB, error) {
+	return sqlx.Open("sqlite3", fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate&_foreign_keys=on&_secure_delete=on", path))
+}
 
-const (
-	ActorSpaceOrga   ActorSpace = "orga"
-	ActorSpacePlayer ActorSpace = "player"
-)
+// SQLiteStore is the EventStore and ActorStore backed by the SQLite database.
+type SQLiteStore struct {
+	db *sqlx.DB
+	// tx is the transaction of the store handed out by Atomic.
+	tx *sqlx.Tx
+	// rewritten is set once events are rewritten within tx, the previous
+	// versions of their pages are scrubbed from the WAL after the commit.
+	rewritten bool
+	// sealed leaves the medical fields of the events it reads sealed, for
+	// the commands which only check the log and run without the key.
+	sealed bool
+}
+
+func NewSQLiteStore(db *sqlx.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
 
-type EventRecordStatus uint64
+// querier returns the transaction of an atomic store, the database otherwise.
+func (s *SQLiteStore) querier() sqlx.Ext {
+	if s.tx != nil {
+		return s.tx
+	}
 
-const (
-	EventRecordStatusPending EventRecordStatus = 1 << iota
-	EventRecordStatusAccepted
-	EventRecordStatusRejected
+	return s.db
+}
 
-	EventRecordStatusAll = EventRecordStatusAccepted | EventRecordStatusRejected | EventRecordStatusPending
-)
+// sqliteTx is a transaction of a store method. Within an atomic store it is a
+// savepoint of the atomic transaction.
+type sqliteTx struct {
+	*sqlx.Tx
+	savepoint bool
+	done      bool
+}
+
+func (s *SQLiteStore) begin() (*sqliteTx, error) {
+	if s.tx == nil {
+		tx, err := s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+
+		return &sqliteTx{Tx: tx}, nil
+	}
+
+	_, err := s.tx.Exec(`SAVEPOINT store`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqliteTx{Tx: s.tx, savepoint: true}, nil
+}
 
-func (e EventRecordStatus) MarshalJSON() ([]byte, error) {
-	switch e {
-	case EventRecordStatusAccepted:
-		return []byte(`"accepted"`), nil
-	case EventRecordStatusPending:
-		return []byte(`"pending"`), nil
-	case EventRecordStatusRejected:
-		return []byte(`"rejected"`), nil
-	default:
-		return nil, fmt.Errorf("EventRecordStatus %d not supported", e)
+func (tx *sqliteTx) Commit() error {
+	if !tx.savepoint {
+		return tx.Tx.Commit()
 	}
+
+	tx.done = true
+
+	_, err := tx.Exec(`RELEASE store`)
+
+	return err
 }
 
-func LinkState(db *sqlx.DB, actorID int64, publicKey []byte) (ActorSpace, error) {
+func (tx *sqliteTx) Rollback() error {
+	if !tx.savepoint {
+		return tx.Tx.Rollback()
+	}
+
+	if tx.done {
+		return nil
+	}
+
+	tx.done = true
+
+	_, err := tx.Exec(`ROLLBACK TO store`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`RELEASE store`)
+
+	return err
+}
+
+// Atomic runs fn in a single immediate transaction, which holds the write
+// lock of the database until fn returns.
+func (s *SQLiteStore) Atomic(fn func(store EventStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	atomic := &SQLiteStore{db: s.db, tx: tx}
+
+	err = fn(atomic)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	if atomic.rewritten {
+		s.checkpoint()
+	}
+
+	return nil
+}
+
+// rewrote scrubs the previous versions of the rewritten events once they are
+// committed.
+func (s *SQLiteStore) rewrote() {
+	if s.tx != nil {
+		s.rewritten = true
+
+		return
+	}
+
+	s.checkpoint()
+}
+
+// checkpoint moves the committed pages of the WAL to the database file and
+// truncates the WAL, which still holds the previous versions of the pages.
+// The writes are committed already, a failure is only logged.
+func (s *SQLiteStore) checkpoint() {
+	var busy, frames, checkpointed int
+
+	err := s.db.QueryRowx(`PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &frames, &checkpointed)
+	if err != nil {
+		log.Printf("wal checkpoint: %v", err)
+
+		return
+	}
+
+	if busy != 0 {
+		log.Printf("wal checkpoint: database busy, %d of %d frames checkpointed", checkpointed, frames)
+	}
+}
+
+func (s *SQLiteStore) InsertActor(space ActorSpace) (int64, error) {
+	var id int64
+
+	err := s.querier().QueryRowx(`INSERT INTO actors (space) VALUES (?) RETURNING id`, space).Scan(&id)
+	if err != nil {
+		return -1, fmt.Errorf("query: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *SQLiteStore) LinkState(actorID int64, publicKey []byte) (ActorSpace, error) {
 	var space ActorSpace
 
-	err := db.QueryRowx(`
+	err := s.querier().QueryRowx(`
 	SELECT
 	  actors.space
 	FROM actors
@@ -57,11 +195,13 @@ func LinkState(db *sqlx.DB, actorID int64, publicKey []byte) (ActorSpace, error)
 		return "", fmt.Errorf("query: %w", err)
 	}
 
-	tx, err := db.Beginx()
+	tx, err := s.begin()
 	if err != nil {
 		return "", fmt.Errorf("begin: %w", err)
 	}
 
+	defer tx.Rollback()
+
 	var publicKeyID int64
 	err = tx.QueryRowx(`INSERT INTO public_keys (public_key) VALUES (?) RETURNING id`, publicKey).Scan(&publicKeyID)
 	if err != nil {
@@ -81,10 +221,10 @@ func LinkState(db *sqlx.DB, actorID int64, publicKey []byte) (ActorSpace, error)
 	return space, nil
 }
 
-func InsertAuthKey(db *sqlx.DB, actorID int64) (string, error) {
+func (s *SQLiteStore) InsertAuthKey(actorID int64) (string, error) {
 	key := cryptorand.Text()
 
-	_, err := db.Exec(`INSERT INTO auth_keys (key, actor_id, redeemed_at) VALUES (?, ?, NULL)`, key, actorID)
+	_, err := s.querier().Exec(`INSERT INTO auth_keys (key, actor_id, redeemed_at) VALUES (?, ?, NULL)`, key, actorID)
 	if err != nil {
 		return "", fmt.Errorf("exec: %w", err)
 	}
@@ -92,30 +232,10 @@ func InsertAuthKey(db *sqlx.DB, actorID int64) (string, error) {
 	return key, nil
 }
 
-func FindActorIDByHandle(db *sqlx.DB, handle string) (int64, error) {
-	records, err := GetEvents(db, -1, EventRecordStatusAccepted)
-	if err != nil {
-		return -1, fmt.Errorf("get events: %w", err)
-	}
-
-	for _, record := range records {
-		event := record.Event
-
-		switch v := event.Msg.(type) {
-		case *proto.Event_SeedActor:
-			if v.SeedActor.Handle == handle {
-				return record.SourceActorID, nil
-			}
-		}
-	}
-
-	return -1, fmt.Errorf("handle not found for handle %q", handle)
-}
-
-func GetActorSpaceByActorID(db *sqlx.DB, actorID int64) (ActorSpace, error) {
+func (s *SQLiteStore) GetActorSpaceByActorID(actorID int64) (ActorSpace, error) {
 	var space ActorSpace
 
-	return space, db.QueryRowx(`
+	return space, s.querier().QueryRowx(`
 	SELECT
 	  space
 	FROM actors
@@ -126,10 +246,10 @@ func GetActorSpaceByActorID(db *sqlx.DB, actorID int64) (ActorSpace, error) {
 	)
 }
 
-func UseAuthKey(db *sqlx.DB, key string) (int64, error) {
+func (s *SQLiteStore) UseAuthKey(key string) (int64, error) {
 	var actorID int64
 
-	err := db.QueryRowx(
+	err := s.querier().QueryRowx(
 		`UPDATE auth_keys SET redeemed_at=? WHERE key=? RETURNING actor_id`,
 		time.Now().UTC().Unix(),
 		key,
@@ -141,11 +261,11 @@ func UseAuthKey(db *sqlx.DB, key string) (int64, error) {
 	return actorID, nil
 }
 
-func GetState(db *sqlx.DB, publicKey []byte) (int64, ActorSpace, error) {
+func (s *SQLiteStore) GetState(publicKey []byte) (int64, ActorSpace, error) {
 	var id int64
 	var space ActorSpace
 
-	err := db.QueryRowx(`
+	err := s.querier().QueryRowx(`
 	SELECT
 	  actors_public_keys.actor_id,
 	  actors.space
@@ -164,11 +284,13 @@ func GetState(db *sqlx.DB, publicKey []byte) (int64, ActorSpace, error) {
 		return id, space, nil
 	}
 
-	tx, err := db.Beginx()
+	tx, err := s.begin()
 	if err != nil {
 		return -1, "", fmt.Errorf("begin: %w", err)
 	}
 
+	defer tx.Rollback()
+
 	var publicKeyID int64
 	err = tx.QueryRowx(`INSERT INTO public_keys (public_key) VALUES (?) RETURNING id`, publicKey).Scan(&publicKeyID)
 	if err != nil {
@@ -193,38 +315,78 @@ func GetState(db *sqlx.DB, publicKey []byte) (int64, ActorSpace, error) {
 	return id, space, nil
 }
 
-func InsertEvents(db *sqlx.DB, sourceActorID int64, events []*proto.Event) ([]int64, error) {
-	tx, err := db.Beginx()
+// eventClock allocates the events ts. It is seeded with the greatest ts of the
+// events table on each insert so that ts keep increasing across restarts and
+// other processes writing to the same database.
+var eventClock = NewClock()
+
+func (s *SQLiteStore) InsertEvents(sourceActorID int64, batch *Batch, events []*proto.Event) ([]int64, error) {
+	tx, err := s.begin()
 	if err != nil {
 		return nil, fmt.Errorf("begin: %w", err)
 	}
 
-	ids := make([]int64, len(events))
+	defer tx.Rollback()
+
+	var maxTs int64
+
+	err = tx.QueryRowx(`SELECT COALESCE(MAX(ts), -1) FROM events`).Scan(&maxTs)
+	if err != nil {
+		return nil, fmt.Errorf("query max ts: %w", err)
+	}
+
+	eventClock.Observe(maxTs)
+
+	ids := eventClock.Next(len(events))
 
-	ts := time.Now().UnixMilli()*1000 + rand.Int63n(1000)
+	previous, err := chainEvents(tx.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("chain: %w", err)
+	}
+
+	var batchID sql.NullInt64
+
+	if batch != nil {
+		err = tx.QueryRowx(`
+		INSERT INTO batches (public_key_id, token, payload)
+		SELECT id, ?, ? FROM public_keys WHERE public_key=?
+		RETURNING id`,
+			batch.Token,
+			fieldCipher.SealPayload(batch.Payload),
+			batch.PublicKey,
+		).Scan(&batchID)
+		if err != nil {
+			return nil, fmt.Errorf("insert batch: %w", err)
+		}
+	}
 
 	for i, event := range events {
-		ts += int64(i)
+		ts := ids[i]
 
 		event.Ts = ts
 
-		data, err := protolib.Marshal(event)
+		data, err := protolib.Marshal(fieldCipher.SealEvent(event))
 		if err != nil {
 			return nil, fmt.Errorf("marshalling event to proto: %w", err)
 		}
 
+		previous = EventHash(previous, ts, sourceActorID, data)
+
+		batchIndex := sql.NullInt64{Int64: int64(i), Valid: batchID.Valid}
+
 		_, err = tx.Exec(
-			"INSERT INTO events (ts, source_actor_id, data, status) VALUES (?,?,?,?)",
+			"INSERT INTO events (ts, source_actor_id, data, status, hash, batch_id, batch_index) VALUES (?,?,?,?,?,?,?)",
 			ts,
 			sourceActorID,
 			data,
 			EventRecordStatusPending,
+			previous,
+			batchID,
+			batchIndex,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("exec: %w", err)
 		}
-
-		ids[i] = ts
 	}
 
 	err = tx.Commit()
@@ -235,20 +397,149 @@ func InsertEvents(db *sqlx.DB, sourceActorID int64, events []*proto.Event) ([]in
 	return ids, nil
 }
 
-type EventRecord struct {
-	SourceActorID int64
-	Event         proto.Event
-	Status        EventRecordStatus
+// chainEvents computes the hashes missing from the events table, left by
+// events stored before the chain existed, and returns the hash of the last
+// event.
+func chainEvents(tx *sqlx.Tx) ([]byte, error) {
+	var last []byte
+
+	err := tx.QueryRowx(`SELECT hash FROM events ORDER BY ts DESC LIMIT 1`).Scan(&last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query last hash: %w", err)
+	}
+
+	if last != nil {
+		return lastRewrite(tx, last)
+	}
+
+	var previous []byte
+
+	var from int64 = -1
+
+	err = tx.QueryRowx(`SELECT ts, hash FROM events WHERE ts < (SELECT MIN(ts) FROM events WHERE hash IS NULL) ORDER BY ts DESC LIMIT 1`).Scan(&from, &previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("query previous hash: %w", err)
+	}
+
+	type row struct {
+		Ts            int64  `db:"ts"`
+		SourceActorID int64  `db:"source_actor_id"`
+		Data          []byte `db:"data"`
+	}
+
+	var rows []row
+
+	err = tx.Select(&rows, `SELECT ts, source_actor_id, data FROM events WHERE ts > ? ORDER BY ts ASC`, from)
+	if err != nil {
+		return nil, fmt.Errorf("query unchained events: %w", err)
+	}
+
+	for _, r := range rows {
+		previous, err = lastRewrite(tx, previous)
+		if err != nil {
+			return nil, err
+		}
+
+		previous = EventHash(previous, r.Ts, r.SourceActorID, r.Data)
+
+		_, err = tx.Exec(`UPDATE events SET hash = ? WHERE ts = ?`, previous, r.Ts)
+		if err != nil {
+			return nil, fmt.Errorf("update hash: %w", err)
+		}
+	}
+
+	return lastRewrite(tx, previous)
+}
+
+// lastRewrite returns the hash of the last rewrite appended after the event
+// hashed head, or head when there is none.
+func lastRewrite(tx sqlx.Queryer, head []byte) ([]byte, error) {
+	if head == nil {
+		return nil, nil
+	}
+
+	var hash []byte
+
+	err := tx.QueryRowx(`SELECT hash FROM events_rewrites WHERE head = ? ORDER BY id DESC LIMIT 1`, head).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return head, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query last rewrite: %w", err)
+	}
+
+	return hash, nil
 }
 
-func GetEvents(db *sqlx.DB, from int64, statusMask EventRecordStatus) ([]EventRecord, error) {
+func (s *SQLiteStore) GetBatch(eventTs int64) (Batch, int, error) {
+	var batch Batch
+	var digests []byte
+	var index int
+
+	err := s.querier().QueryRowx(`
+	SELECT
+	  public_keys.public_key,
+	  batches.token,
+	  batches.payload,
+	  batches.digests,
+	  events.batch_index
+	FROM events
+	JOIN batches ON batches.id = events.batch_id
+	JOIN public_keys ON public_keys.id = batches.public_key_id
+	WHERE events.ts=?`,
+		eventTs,
+	).Scan(&batch.PublicKey, &batch.Token, &batch.Payload, &digests, &index)
+	if err != nil {
+		return batch, -1, fmt.Errorf("query: %w", err)
+	}
+
+	batch.Payload, err = fieldCipher.OpenPayload(batch.Payload)
+	if err != nil {
+		return batch, -1, fmt.Errorf("open payload: %w", err)
+	}
+
+	batch.Digests = splitDigests(digests)
+
+	return batch, index, nil
+}
+
+// joinDigests and splitDigests convert the digests of a batch from and to
+// the digests column, nil digests are NULL.
+func joinDigests(digests [][]byte) []byte {
+	if digests == nil {
+		return nil
+	}
+
+	return bytes.Join(digests, nil)
+}
+
+func splitDigests(data []byte) [][]byte {
+	if data == nil {
+		return nil
+	}
+
+	digests := [][]byte{}
+
+	for len(data) >= sha256.Size {
+		digests = append(digests, data[:sha256.Size])
+		data = data[sha256.Size:]
+	}
+
+	return digests
+}
+
+func (s *SQLiteStore) GetEvents(from int64, statusMask EventRecordStatus) ([]EventRecord, error) {
 	var events []EventRecord
 
-	result, err := db.Queryx(
+	result, err := s.querier().Queryx(
 		`SELECT
 		   source_actor_id,
 		   data,
-		   status
+		   status,
+		   hash
 		FROM events
 		WHERE
 		  ts > ?
@@ -262,33 +553,66 @@ func GetEvents(db *sqlx.DB, from int64, statusMask EventRecordStatus) ([]EventRe
 		return events, fmt.Errorf("query: %w", err)
 	}
 
+	defer result.Close()
+
 	for result.Next() {
 		var event EventRecord
 
-		var data []byte
-
 		err = result.Rows.Scan(
 			&event.SourceActorID,
-			&data,
+			&event.Data,
 			&event.Status,
+			&event.Hash,
 		)
 		if err != nil {
 			return events, fmt.Errorf("scan: %w", err)
 		}
 
-		err = protolib.Unmarshal(data, &event.Event)
+		event.Event = &proto.Event{}
+
+		err = protolib.Unmarshal(event.Data, event.Event)
 		if err != nil {
 			return events, fmt.Errorf("proto unmarshall: %w", err)
 		}
 
+		if !s.sealed {
+			err = fieldCipher.OpenEvent(event.Event)
+			if err != nil {
+				return events, fmt.Errorf("open event %d: %w", event.Event.Ts, err)
+			}
+		}
+
 		events = append(events, event)
 	}
 
+	err = result.Err()
+	if err != nil {
+		return events, fmt.Errorf("rows: %w", err)
+	}
+
 	return events, nil
 }
 
-func UpdateEventStatus(db *sqlx.DB, eventTs int64, status EventRecordStatus) error {
-	result, err := db.Exec(
+func (s *SQLiteStore) CountEvents(to int64, statusMask EventRecordStatus) (int, error) {
+	var count int
+
+	err := s.querier().QueryRowx(`SELECT COUNT(*) FROM events WHERE ts <= ? AND status & ? != 0`, to, statusMask).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("query: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *SQLiteStore) UpdateEventStatus(eventTs int64, status EventRecordStatus, reason string) error {
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
 		`
 		UPDATE events
 		SET status = ?
@@ -310,5 +634,499 @@ func UpdateEventStatus(db *sqlx.DB, eventTs int64, status EventRecordStatus) err
 		return sql.ErrNoRows
 	}
 
+	if status == EventRecordStatusStuttering {
+		_, err = tx.Exec(`INSERT OR REPLACE INTO events_stuttering (ts, reason) VALUES (?, ?)`, eventTs, reason)
+	} else {
+		_, err = tx.Exec(`DELETE FROM events_stuttering WHERE ts=?`, eventTs)
+	}
+	if err != nil {
+		return fmt.Errorf("exec stuttering: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) DeleteEvents(tss []int64) error {
+	if len(tss) == 0 {
+		return nil
+	}
+
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	from := tss[0]
+
+	for _, ts := range tss {
+		_, err = tx.Exec(`DELETE FROM events_stuttering WHERE ts=?`, ts)
+		if err != nil {
+			return fmt.Errorf("exec stuttering: %w", err)
+		}
+
+		result, err := tx.Exec(`DELETE FROM events WHERE ts=?`, ts)
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("RowsAffected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+
+		from = min(from, ts)
+	}
+
+	_, err = tx.Exec(`UPDATE events SET hash = NULL WHERE ts > ?`, from)
+	if err != nil {
+		return fmt.Errorf("exec hash: %w", err)
+	}
+
+	_, err = chainEvents(tx.Tx)
+	if err != nil {
+		return fmt.Errorf("chain: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) RewriteEvents(events []*proto.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	head, previous, err := rewriteHead(tx.Tx)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		previous, err = rewriteEvent(tx.Tx, event, head, previous)
+		if err != nil {
+			return err
+		}
+
+		err = redactBatch(tx.Tx, event)
+		if err != nil {
+			return fmt.Errorf("redact batch: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	s.rewrote()
+
+	return nil
+}
+
+// rewriteHead returns the hash of the last event and the hash of the log the
+// next rewrite chains to.
+func rewriteHead(tx *sqlx.Tx) ([]byte, []byte, error) {
+	var head []byte
+
+	err := tx.QueryRowx(`SELECT hash FROM events ORDER BY ts DESC LIMIT 1`).Scan(&head)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query head: %w", err)
+	}
+
+	previous, err := lastRewrite(tx, head)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return head, previous, nil
+}
+
+// rewriteEvent replaces the data of the stored event having the ts of event
+// and appends the rewrite to previous. It returns the hash of the rewrite.
+func rewriteEvent(tx *sqlx.Tx, event *proto.Event, head, previous []byte) ([]byte, error) {
+	data, err := protolib.Marshal(fieldCipher.SealEvent(event))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling event to proto: %w", err)
+	}
+
+	result, err := tx.Exec(`UPDATE events SET data=? WHERE ts=?`, data, event.Ts)
+	if err != nil {
+		return nil, fmt.Errorf("exec: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("RowsAffected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	dataHash := sha256.Sum256(data)
+	hash := RewriteHash(previous, event.Ts, dataHash[:])
+
+	_, err = tx.Exec(
+		`INSERT INTO events_rewrites (ts, data_hash, head, hash) VALUES (?,?,?,?)`,
+		event.Ts,
+		dataHash[:],
+		head,
+		hash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert rewrite: %w", err)
+	}
+
+	return hash, nil
+}
+
+// SealMedical seals with fieldCipher the medical fields and the batch
+// payloads stored in plaintext before the medical key was set. The sealed
+// events are rewritten, their batches keep their events as signed. It
+// returns the number of sealed events and batches.
+func (s *SQLiteStore) SealMedical() (int, int, error) {
+	if fieldCipher == nil {
+		return 0, 0, ErrMedicalKey
+	}
+
+	tx, err := s.begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var stored [][]byte
+
+	err = tx.Select(&stored, `SELECT data FROM events ORDER BY ts ASC`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("query events: %w", err)
+	}
+
+	var events []*proto.Event
+
+	for _, data := range stored {
+		event := &proto.Event{}
+
+		err = protolib.Unmarshal(data, event)
+		if err != nil {
+			return 0, 0, fmt.Errorf("proto unmarshall: %w", err)
+		}
+
+		if plaintextMedical(event) {
+			events = append(events, event)
+		}
+	}
+
+	if len(events) > 0 {
+		head, previous, err := rewriteHead(tx.Tx)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, event := range events {
+			previous, err = rewriteEvent(tx.Tx, event, head, previous)
+			if err != nil {
+				return 0, 0, fmt.Errorf("event %d: %w", event.Ts, err)
+			}
+		}
+	}
+
+	type batch struct {
+		ID      int64  `db:"id"`
+		Payload []byte `db:"payload"`
+	}
+
+	var batches []batch
+
+	err = tx.Select(&batches, `SELECT id, payload FROM batches WHERE length(payload) > 0 AND substr(payload, 1, ?) != ?`, len(sealedPrefix), []byte(sealedPrefix))
+	if err != nil {
+		return 0, 0, fmt.Errorf("query batches: %w", err)
+	}
+
+	for _, batch := range batches {
+		_, err = tx.Exec(`UPDATE batches SET payload=? WHERE id=?`, fieldCipher.SealPayload(batch.Payload), batch.ID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("exec batch %d: %w", batch.ID, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, fmt.Errorf("commit: %w", err)
+	}
+
+	s.rewrote()
+
+	return len(events), len(batches), nil
+}
+
+// redactBatch replaces event in the payload of its batch, if any. The batch
+// keeps the digests of its events as signed.
+func redactBatch(tx *sqlx.Tx, event *proto.Event) error {
+	var batchID int64
+	var index int
+	var payload, digests []byte
+
+	err := tx.QueryRowx(`
+	SELECT batches.id, events.batch_index, batches.payload, batches.digests
+	FROM events
+	JOIN batches ON batches.id = events.batch_id
+	WHERE events.ts=?`,
+		event.Ts,
+	).Scan(&batchID, &index, &payload, &digests)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	payload, err = fieldCipher.OpenPayload(payload)
+	if err != nil {
+		return fmt.Errorf("open payload: %w", err)
+	}
+
+	if digests == nil {
+		signed, err := EventDigests(payload)
+		if err != nil {
+			return err
+		}
+
+		digests = joinDigests(signed)
+	}
+
+	payload, err = redactPayload(payload, index, event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE batches SET payload=?, digests=? WHERE id=?`, fieldCipher.SealPayload(payload), digests, batchID)
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) GetRewrites() ([]Rewrite, error) {
+	var rewrites []Rewrite
+
+	rows, err := s.querier().Queryx(`SELECT ts, data_hash, head, hash FROM events_rewrites ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rewrite Rewrite
+
+		err = rows.Scan(&rewrite.Ts, &rewrite.DataHash, &rewrite.Head, &rewrite.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+
+		rewrites = append(rewrites, rewrite)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return rewrites, nil
+}
+
+func (s *SQLiteStore) GetHead() (int64, []byte, error) {
+	var ts int64
+	var head []byte
+
+	err := s.querier().QueryRowx(`SELECT ts, hash FROM events ORDER BY ts DESC LIMIT 1`).Scan(&ts, &head)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("query last hash: %w", err)
+	}
+
+	head, err = lastRewrite(s.querier(), head)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return ts, head, nil
+}
+
+func (s *SQLiteStore) GetIdempotentResults(actorID int64, key string, since int64) ([]RunEventResult, error) {
+	var data []byte
+
+	err := s.querier().QueryRowx(
+		`SELECT results FROM idempotency_keys WHERE actor_id=? AND key=? AND created_at >= ?`,
+		actorID,
+		key,
+		since,
+	).Scan(&data)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	var results []RunEventResult
+
+	err = json.Unmarshal(data, &results)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLiteStore) InsertIdempotentResults(actorID int64, key string, createdAt int64, results []RunEventResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	_, err = s.querier().Exec(
+		`INSERT OR REPLACE INTO idempotency_keys (actor_id, key, created_at, results) VALUES (?, ?, ?, ?)`,
+		actorID,
+		key,
+		createdAt,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) DeleteIdempotencyKeys(before int64) error {
+	_, err := s.querier().Exec(`DELETE FROM idempotency_keys WHERE created_at < ?`, before)
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) GetStutteringEvents() ([]StutteringEventRecord, error) {
+	var events []StutteringEventRecord
+
+	result, err := s.querier().Queryx(
+		`SELECT
+		   events.source_actor_id,
+		   events.data,
+		   events.status,
+		   events_stuttering.reason
+		FROM events
+		JOIN events_stuttering ON events_stuttering.ts = events.ts
+		WHERE events.status = ?
+		ORDER BY events.ts ASC`,
+		EventRecordStatusStuttering,
+	)
+	if err != nil {
+		return events, fmt.Errorf("query: %w", err)
+	}
+
+	defer result.Close()
+
+	for result.Next() {
+		var event StutteringEventRecord
+
+		var data []byte
+
+		err = result.Rows.Scan(
+			&event.SourceActorID,
+			&data,
+			&event.Status,
+			&event.Reason,
+		)
+		if err != nil {
+			return events, fmt.Errorf("scan: %w", err)
+		}
+
+		event.Event = &proto.Event{}
+
+		err = protolib.Unmarshal(data, event.Event)
+		if err != nil {
+			return events, fmt.Errorf("proto unmarshall: %w", err)
+		}
+
+		err = fieldCipher.OpenEvent(event.Event)
+		if err != nil {
+			return events, fmt.Errorf("open event %d: %w", event.Event.Ts, err)
+		}
+
+		events = append(events, event)
+	}
+
+	err = result.Err()
+	if err != nil {
+		return events, fmt.Errorf("rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *SQLiteStore) ResolveStutteringEvent(eventTs int64) error {
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`UPDATE events SET status = ? WHERE ts=? AND status = ?`,
+		EventRecordStatusResolved,
+		eventTs,
+		EventRecordStatusStuttering,
+	)
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RowsAffected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`DELETE FROM events_stuttering WHERE ts=?`, eventTs)
+	if err != nil {
+		return fmt.Errorf("exec stuttering: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+// EditionInitial names the edition preceding the first edition event.
+const EditionInitial = "initial"
+
+var ErrEditionNotFound = errors.New("edition not found")
+
+// EditionName returns the name of the edition opened by event. Legacy Reset
+// events carry no name, their edition is named after their ts.
+func EditionName(event *proto.Event) (string, bool) {
+	switch v := event.Msg.(type) {
+	case *proto.Event_Edition:
+		return v.Edition.Name, true
+	case *proto.Event_Reset_:
+		return strconv.FormatInt(event.Ts, 10), true
+	default:
+		return "", false
+	}
+}
+
+// editionScoped tells whether event belongs to the edition it was accepted
+// in. Actors, permissions, players and groups carry over from an edition to
+// the next, the members of a group come with the characters.
+func editionScoped(event *proto.Event) bool {
+	switch event.Msg.(type) {
+	case *proto.Event_PlayerPerson, *proto.Event_PlayerCharacter, *proto.Event_PlayerCharacterOrgaEdit, *proto.Event_CharacterReview,
+		*proto.Event_CharacterTransfer, *proto.Event_Relationship:
+		return true
+	default:
+		return false
+	}
+}
+
+// ScopeEdition keeps from the events of a projection the ones visible in an
+// edition: the events carried over from any edition, the event opening the
+// edition and the edition scoped events accepted during it. An empty edition
+// stands for the current one.
+func ScopeEdition(events []*proto.Event, edition string) ([]*proto.Event, error) {
+	target := 0
+	current := 0
+	found := edition == "" || edition == EditionInitial
+
+	for _, event := range events {
+		name, ok := EditionName(event)
+		if !ok {
+			continue
+		}
+
+		current++
+
+		if name == edition {
+			target = current
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrEditionNotFound, edition)
+	}
+
+	if edition == "" {
+		target = current
+	}
+
+	scoped := make([]*proto.Event, 0, len(events))
+	current = 0
+
+	for _, event := range events {
+		if _, ok := EditionName(event); ok {
+			current++
+
+			if current == target {
+				scoped = append(scoped, event)
+			}
+
+			continue
+		}
+
+		if editionScoped(event) && current != target {
+			continue
+		}
+
+		scoped = append(scoped, event)
+	}
+
+	return scoped, nil
+}
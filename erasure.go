@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+// ErasePlayer replaces the EventPlayerPerson of playerID preceding the
+// erasure at erasureTs by EventErased tombstones. The log stays replayable:
+// a tombstone leaves the space untouched and keeps the status of the event
+// it replaces.
+func ErasePlayer(store EventStore, playerID string, erasureTs int64) error {
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		return fmt.Errorf("get events: %w", err)
+	}
+
+	var tombstones []*proto.Event
+
+	for _, record := range records {
+		person := record.Event.GetPlayerPerson()
+		if person == nil || person.PlayerId != playerID || record.Event.Ts >= erasureTs {
+			continue
+		}
+
+		tombstones = append(tombstones, &proto.Event{
+			Ts: record.Event.Ts,
+			Msg: &proto.Event_Erased{
+				Erased: &proto.EventErased{
+					PlayerId:  playerID,
+					ErasureTs: erasureTs,
+					Rejected:  record.Status != EventRecordStatusAccepted,
+				},
+			},
+		})
+	}
+
+	err = store.RewriteEvents(tombstones)
+	if err != nil {
+		return fmt.Errorf("rewrite events: %w", err)
+	}
+
+	return nil
+}
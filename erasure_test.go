@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestErasePlayer(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) Store{
+		"sqlite": func(t *testing.T) Store { return openTestStore(t) },
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			projections := NewProjections()
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			person := playerPerson("player:coffee-art")
+			person.Health = "allergic to peanuts"
+
+			results := submitBatch(t, store, key,
+				seedActor("art-coffee"),
+				seedPlayer("art-coffee", "player:coffee-art"),
+				&proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}},
+			)
+			seedTs, personTs := results[1].Ts, results[2].Ts
+
+			// A rejected edit is erased as well.
+			invalid := playerPerson("player:coffee-art")
+			invalid.Health = "allergic to peanuts"
+			invalid.Contact = ""
+			submitBatch(t, store, key, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: invalid}})
+
+			orgaID, err := store.InsertActor(ActorSpaceOrga)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{permission(orgaID, PermissionOrga, false)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Snapshots built before the erasure must drop the data too.
+			_, err = FetchEvents(store, projections, orgaID, ActorSpaceOrga, "", -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			erasure := &proto.Event{Msg: &proto.Event_PlayerErasure{PlayerErasure: &proto.EventPlayerErasure{PlayerId: "player:coffee-art"}}}
+
+			if results := submitBatch(t, store, key, erasure); results[0].Status != EventRecordStatusRejected {
+				t.Fatalf("erasure by the player: %+v", results[0])
+			}
+
+			forged := &proto.Event{Msg: &proto.Event_Erased{Erased: &proto.EventErased{PlayerId: "player:coffee-art", ErasureTs: 1 << 62}}}
+
+			results, err = InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{forged})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if results[0].Status != EventRecordStatusRejected {
+				t.Fatalf("submitted tombstone: %+v", results[0])
+			}
+
+			before := storedStatuses(t, store)
+			personHash := storedHash(t, store, personTs)
+
+			results, err = InsertAndCheckEvents(store, NewValidation(), -1, orgaID, nil, []*proto.Event{erasure})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if results[0].Status != EventRecordStatusAccepted {
+				t.Fatalf("erasure: %+v", results[0])
+			}
+
+			records, err := store.GetEvents(-1, EventRecordStatusAll)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, record := range records {
+				if bytes.Contains(record.Data, []byte("peanuts")) {
+					t.Errorf("event %d still holds personal data: %v", record.Event.Ts, record.Event)
+				}
+			}
+
+			batch, _, err := store.GetBatch(personTs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.Contains(batch.Payload, []byte("peanuts")) {
+				t.Errorf("batch of an erased event still holds personal data")
+			}
+
+			// The other events of the batch keep their proof, the history of
+			// the chain is left as it was.
+			_, err = VerifyAuthorship(store, seedTs)
+			if err != nil {
+				t.Errorf("authorship of an event batched with an erased one: %v", err)
+			}
+
+			_, err = VerifyAuthorship(store, personTs)
+			if err == nil {
+				t.Errorf("authorship of an erased event verified")
+			}
+
+			if hash := storedHash(t, store, personTs); !bytes.Equal(hash, personHash) {
+				t.Errorf("hash of the erased event changed from %x to %x", personHash, hash)
+			}
+
+			rewrites, err := store.GetRewrites()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rewrites) != 2 {
+				t.Errorf("got %d rewrites, want 2", len(rewrites))
+			}
+
+			// The head chains to the rewrites appended after the last event.
+			head, err := VerifyChain(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			headTs, got, err := store.GetHead()
+			if err != nil || headTs != results[0].Ts || !bytes.Equal(got, head) {
+				t.Errorf("got head %d %x, %v, want %d %x", headTs, got, err, results[0].Ts, head)
+			}
+
+			after := storedStatuses(t, store)
+			delete(after, results[0].Ts)
+
+			if diff := cmp.Diff(before, after); diff != "" {
+				t.Errorf("statuses after erasure (-before +after):\n%s", diff)
+			}
+
+			// The events appended after chain to the rewrites.
+			submitBatch(t, store, key, seedActor("art-coffee"))
+
+			err = VerifyReplay(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = VerifyChain(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, projections := range []*Projections{projections, NewProjections()} {
+				events, err := FetchEvents(store, projections, orgaID, ActorSpaceOrga, "", -1)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var erased bool
+
+				for _, event := range events {
+					if event.GetPlayerPerson() != nil {
+						t.Errorf("orga still gets %v", event)
+					}
+
+					erased = erased || event.GetPlayerErasure() != nil
+				}
+
+				if !erased {
+					t.Error("orga does not get the erasure")
+				}
+			}
+		})
+	}
+}
+
+// storedHash returns the hash of the event at ts in the chain.
+func storedHash(t *testing.T, store EventStore, ts int64) []byte {
+	t.Helper()
+
+	records, err := store.GetEvents(ts-1, EventRecordStatusAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) == 0 || records[0].Event.Ts != ts {
+		t.Fatalf("event %d not found", ts)
+	}
+
+	return records[0].Hash
+}
+
+func TestErasePlayerScrubsDatabaseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thekeeper.db")
+
+	db, err := OpenDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	_, err = Migrate(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewSQLiteStore(db)
+
+	orgaID, err := store.InsertActor(ActorSpaceOrga)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	person := playerPerson("player:coffee-art")
+	person.Surname = "Zanzibar-Quetzalcoatl"
+
+	submitBatch(t, store, key,
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		&proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}},
+	)
+
+	validation := NewValidation()
+
+	_, err = InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{permission(orgaID, PermissionOrga, false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	erasure := &proto.Event{Msg: &proto.Event_PlayerErasure{PlayerErasure: &proto.EventPlayerErasure{PlayerId: "player:coffee-art"}}}
+
+	results, err := InsertAndCheckEvents(store, validation, -1, orgaID, nil, []*proto.Event{erasure})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("erasure: %+v", results[0])
+	}
+
+	for _, suffix := range []string{"", "-wal"} {
+		data, err := os.ReadFile(path + suffix)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+
+		if bytes.Contains(data, []byte(person.Surname)) {
+			t.Errorf("%s still holds the erased surname", filepath.Base(path+suffix))
+		}
+	}
+}
+
+func TestErasureAcceptedOnReplay(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) Store{
+		"sqlite": func(t *testing.T) Store { return openTestStore(t) },
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			orgaID, err := store.InsertActor(ActorSpaceOrga)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := submitBatch(t, store, key,
+				seedActor("art-coffee"),
+				seedPlayer("art-coffee", "player:coffee-art"),
+				&proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}},
+			)
+			personTs := results[2].Ts
+
+			_, err = InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{permission(orgaID, PermissionOrga, false)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The erasure is logged but left pending, it is only accepted
+			// by the replay of the next submission.
+			erasure := &proto.Event{Msg: &proto.Event_PlayerErasure{PlayerErasure: &proto.EventPlayerErasure{PlayerId: "player:coffee-art"}}}
+
+			_, err = store.InsertEvents(orgaID, nil, []*proto.Event{erasure})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{seedActor("latte")})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if status := storedStatuses(t, store)[erasure.Ts]; status != EventRecordStatusAccepted {
+				t.Fatalf("erasure stored as %v", status)
+			}
+
+			records, err := store.GetEvents(-1, EventRecordStatusAll)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, record := range records {
+				if record.Event.Ts == personTs && record.Event.GetErased() == nil {
+					t.Errorf("event %d not erased: %v", personTs, record.Event)
+				}
+			}
+
+			err = VerifyReplay(store)
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = VerifyChain(store)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
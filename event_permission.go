@@ -2,25 +2,202 @@ package main
 
 import (
 	"fmt"
+	"maps"
 
 	"github.com/ebenaum/thekeeper/proto"
 )
 
 const (
-	PermissionRoot = "root"
-	PermissionOrga = "orga"
+	PermissionRoot         = "root"
+	PermissionOrga         = "orga"
+	PermissionOrgaReadOnly = "orga-readonly"
+	PermissionScenarist    = "scenarist"
+	PermissionLogistics    = "logistics"
+	PermissionMedical      = "medical"
 )
 
+// Capability is a set of actions an actor is allowed to perform.
+type Capability uint
+
+const (
+	// CapabilityManage covers the permissions, the editions and the univers,
+	// and implies every other capability.
+	CapabilityManage Capability = 1 << iota
+	// CapabilityPlay lets an actor register its own players.
+	CapabilityPlay
+	CapabilityReadPlayers
+	CapabilityEditPlayers
+	CapabilityReadCharacters
+	CapabilityEditCharacters
+	// CapabilityLinkPlayers lets an actor create the auth keys of players.
+	CapabilityLinkPlayers
+	// CapabilityReadMedical lets an actor read the health and the emergency
+	// contact of the players, see MedicalFields.
+	CapabilityReadMedical
+)
+
+// Roles maps the permissions to their capabilities. Actors without a
+// permission are players.
+var Roles = map[string]Capability{
+	"":                     CapabilityPlay,
+	PermissionRoot:         CapabilityManage | CapabilityPlay,
+	PermissionOrga:         CapabilityReadPlayers | CapabilityEditPlayers | CapabilityReadCharacters | CapabilityEditCharacters | CapabilityLinkPlayers,
+	PermissionOrgaReadOnly: CapabilityReadPlayers | CapabilityReadCharacters,
+	PermissionScenarist:    CapabilityReadCharacters | CapabilityEditCharacters,
+	PermissionLogistics:    CapabilityReadPlayers | CapabilityLinkPlayers,
+}
+
+// Grants maps the permissions granted next to the role of an actor to their
+// capabilities, such as the safety officer of the orgas reading the medical
+// fields.
+var Grants = map[string]Capability{
+	PermissionMedical: CapabilityReadPlayers | CapabilityReadMedical,
+}
+
+// EventCapabilities returns the capabilities needed to read event and to act
+// on it out of the log, such as resolving it once stuttering.
+func EventCapabilities(event *proto.Event) (read Capability, edit Capability) {
+	switch event.Msg.(type) {
+	case *proto.Event_SeedPlayer, *proto.Event_PlayerPerson, *proto.Event_PlayerErasure:
+		return CapabilityReadPlayers, CapabilityEditPlayers
+	case *proto.Event_PlayerCharacter, *proto.Event_PlayerCharacterOrgaEdit, *proto.Event_CharacterReview,
+		*proto.Event_CharacterTransfer, *proto.Event_Group, *proto.Event_Relationship:
+		return CapabilityReadCharacters, CapabilityEditCharacters
+	case *proto.Event_SeedActor, *proto.Event_RenameActor, *proto.Event_ActorActivation, *proto.Event_Permission:
+		return CapabilityReadPlayers, CapabilityManage
+	case *proto.Event_Reset_, *proto.Event_Edition:
+		// Every app starts over on a new edition.
+		return 0, CapabilityManage
+	default:
+		return CapabilityManage, CapabilityManage
+	}
+}
+
 type Permission struct {
 	Actors map[int64]string
+	// Granted holds the Grants of each actor, nil until one is granted.
+	Granted map[int64]map[string]struct{}
 }
 
-func (p Permission) Process(actorID int64, event *proto.EventPermission) error {
-	if p.Actors[actorID] != PermissionRoot {
+// Has reports whether c includes capability, CapabilityManage includes them
+// all.
+func (c Capability) Has(capability Capability) bool {
+	return c&CapabilityManage != 0 || c&capability == capability
+}
+
+// Capabilities returns the capabilities of the role of actorID and of the
+// permissions granted next to it.
+func (p Permission) Capabilities(actorID int64) Capability {
+	capabilities, known := Roles[p.Actors[actorID]]
+	if !known {
+		capabilities = Roles[""]
+	}
+
+	for permission := range p.Granted[actorID] {
+		capabilities |= Grants[permission]
+	}
+
+	return capabilities
+}
+
+// Can reports whether actorID has capability.
+func (p Permission) Can(actorID int64, capability Capability) bool {
+	return p.Capabilities(actorID).Has(capability)
+}
+
+// Process grants or revokes a permission. The events logged before the
+// permissions were checked named any permission and any actor, they are
+// still accepted unless submitted.
+func (p *Permission) Process(actorID int64, event *proto.EventPermission, submitted bool) error {
+	if !p.Can(actorID, CapabilityManage) {
 		return fmt.Errorf("not authorized to perform that action")
 	}
 
-	p.Actors[event.ActorId] = event.Permission
+	if event.ActorId == 0 && submitted {
+		return fmt.Errorf("the permission of root cannot change")
+	}
+
+	_, granted := Grants[event.Permission]
+
+	if event.Revoke {
+		_, has := p.Granted[event.ActorId][event.Permission]
+		if !granted {
+			has = p.Actors[event.ActorId] == event.Permission
+		}
+
+		if !has {
+			return fmt.Errorf("actor %d does not have permission %q", event.ActorId, event.Permission)
+		}
+	} else if _, exists := Roles[event.Permission]; submitted && !granted && (!exists || event.Permission == "") {
+		return fmt.Errorf("unknown permission %q", event.Permission)
+	}
+
+	p.apply(event)
 
 	return nil
 }
+
+func (p Permission) clone() Permission {
+	clone := Permission{
+		Actors: maps.Clone(p.Actors),
+	}
+
+	for actorID, granted := range p.Granted {
+		if clone.Granted == nil {
+			clone.Granted = map[int64]map[string]struct{}{}
+		}
+
+		clone.Granted[actorID] = maps.Clone(granted)
+	}
+
+	return clone
+}
+
+// apply grants or revokes the permission of an accepted event. The Grants
+// are added next to the role, a role replaces the previous one. The role of
+// root is left as is, an unknown role counts as none.
+func (p *Permission) apply(event *proto.EventPermission) {
+	if event.ActorId == 0 {
+		return
+	}
+
+	if _, granted := Grants[event.Permission]; granted {
+		if event.Revoke {
+			delete(p.Granted[event.ActorId], event.Permission)
+
+			return
+		}
+
+		if p.Granted == nil {
+			p.Granted = map[int64]map[string]struct{}{}
+		}
+
+		if p.Granted[event.ActorId] == nil {
+			p.Granted[event.ActorId] = map[string]struct{}{}
+		}
+
+		p.Granted[event.ActorId][event.Permission] = struct{}{}
+
+		return
+	}
+
+	if event.Revoke {
+		delete(p.Actors, event.ActorId)
+
+		return
+	}
+
+	p.Actors[event.ActorId] = event.Permission
+}
+
+// ActorCapabilities returns the capabilities the accepted events of store
+// give to actorID.
+func (v *Validation) ActorCapabilities(store EventStore, actorID int64) (Capability, error) {
+	var capabilities Capability
+
+	err := v.read(store, func(view *validationView) {
+		capabilities = view.permission.Capabilities(actorID)
+	})
+
+	return capabilities, err
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func permission(actorID int64, role string, revoke bool) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_Permission{Permission: &proto.EventPermission{ActorId: actorID, Permission: role, Revoke: revoke}}}
+}
+
+func TestPermissionGrantRevoke(t *testing.T) {
+	space := NewSpaceValidation()
+
+	for i, test := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+		accepted      bool
+	}{
+		{0, permission(1, PermissionOrga, false), true},
+		{0, permission(2, "admin", false), false},
+		{0, permission(2, "", false), false},
+		{1, permission(2, PermissionScenarist, false), false},
+		{0, permission(0, PermissionOrga, false), false},
+		{0, permission(1, PermissionScenarist, true), false},
+		{0, permission(1, PermissionOrga, true), true},
+		{0, permission(1, PermissionOrga, true), false},
+		{0, permission(1, PermissionLogistics, false), true},
+	} {
+		err := space.Submit(test.sourceActorID, test.event)
+		if (err == nil) != test.accepted {
+			t.Errorf("#%d: accepted %v, got %v", i, test.accepted, err)
+		}
+	}
+
+	if role := space.Permission.Actors[1]; role != PermissionLogistics {
+		t.Errorf("actor 1 has permission %q, want %q", role, PermissionLogistics)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(2, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}})
+	insert(2, playerCharacter("player:coffee-art", "character:1"))
+
+	for _, test := range []struct {
+		role        string
+		canEdit     bool
+		canOrgaEdit bool
+		canLink     bool
+	}{
+		{PermissionOrga, true, true, true},
+		{PermissionOrgaReadOnly, false, false, false},
+		{PermissionScenarist, false, true, false},
+		{PermissionLogistics, false, false, true},
+	} {
+		if result := insert(0, permission(1, test.role, false)); result.Status != EventRecordStatusAccepted {
+			t.Fatalf("%s: grant: %+v", test.role, result)
+		}
+
+		person := &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}}
+		if result := insert(1, person); (result.Status == EventRecordStatusAccepted) != test.canEdit {
+			t.Errorf("%s: person edit: %+v", test.role, result)
+		}
+
+		if result := insert(1, orgaEdit("character:1")); (result.Status == EventRecordStatusAccepted) != test.canOrgaEdit {
+			t.Errorf("%s: orga edit: %+v", test.role, result)
+		}
+
+		events, err := FetchEvents(store, NewProjections(), 1, ActorSpaceOrga, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var players, persons, characters int
+
+		for _, event := range events {
+			switch event.Msg.(type) {
+			case *proto.Event_SeedPlayer:
+				players++
+			case *proto.Event_PlayerPerson:
+				persons++
+			case *proto.Event_PlayerCharacter:
+				characters++
+			}
+		}
+
+		if (players == 1) != (test.role != PermissionScenarist) || (persons > 0) != (test.role != PermissionScenarist) || (characters > 0) != (test.role != PermissionLogistics) {
+			t.Errorf("%s: sees %d players, %d persons, %d characters", test.role, players, persons, characters)
+		}
+
+		capabilities, err := validation.ActorCapabilities(store, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if capabilities.Has(CapabilityLinkPlayers) != test.canLink {
+			t.Errorf("%s: capabilities %b", test.role, capabilities)
+		}
+	}
+
+	insert(0, permission(1, PermissionLogistics, true))
+
+	if capabilities, err := validation.ActorCapabilities(store, 1); err != nil || capabilities != CapabilityPlay {
+		t.Errorf("revoked actor has capabilities %b, %v", capabilities, err)
+	}
+}
+
+func TestMedicalNextToRole(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	person := playerPerson("player:coffee-art")
+	person.Health = "allergic to peanuts"
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(2, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}})
+
+	// The safety officer is an orga too.
+	for _, role := range []string{PermissionOrga, PermissionMedical} {
+		if result := insert(0, permission(1, role, false)); result.Status != EventRecordStatusAccepted {
+			t.Fatalf("grant %s: %+v", role, result)
+		}
+	}
+
+	capabilities, err := validation.ActorCapabilities(store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Roles[PermissionOrga] | CapabilityReadMedical
+	if capabilities != want {
+		t.Errorf("orga with medical has capabilities %b, want %b", capabilities, want)
+	}
+
+	health := func() string {
+		t.Helper()
+
+		events, err := FetchEvents(store, NewProjections(), 1, ActorSpaceOrga, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var health string
+
+		for _, event := range events {
+			if person := event.GetPlayerPerson(); person != nil {
+				health = person.Health
+			}
+		}
+
+		return health
+	}
+
+	if got := health(); got != person.Health {
+		t.Errorf("orga with medical reads health %q", got)
+	}
+
+	if result := insert(1, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}}); result.Status != EventRecordStatusAccepted {
+		t.Errorf("orga with medical lost the person edits: %+v", result)
+	}
+
+	if result := insert(0, permission(1, PermissionMedical, true)); result.Status != EventRecordStatusAccepted {
+		t.Fatalf("revoke medical: %+v", result)
+	}
+
+	if result := insert(0, permission(1, PermissionMedical, true)); result.Status != EventRecordStatusRejected {
+		t.Errorf("revoke medical twice: %+v", result)
+	}
+
+	if capabilities, err := validation.ActorCapabilities(store, 1); err != nil || capabilities != Roles[PermissionOrga] {
+		t.Errorf("orga without medical has capabilities %b, %v", capabilities, err)
+	}
+
+	if got := health(); got != "" {
+		t.Errorf("orga without medical reads health %q", got)
+	}
+}
+
+func TestReplayBaselinePermissions(t *testing.T) {
+	store := newTestStore(t)
+
+	// A log of the baseline: root named any permission and any actor, and
+	// any actor could reset.
+	for _, inserted := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+	}{
+		{2, seedActor("art-coffee")},
+		{0, permission(1, PermissionOrga, false)},
+		{0, permission(2, "admin", false)},
+		{0, permission(0, PermissionOrga, false)},
+		{0, permission(1, "", false)},
+		{2, seedPlayer("art-coffee", "player:coffee-art")},
+		{2, &proto.Event{Msg: &proto.Event_Reset_{}}},
+	} {
+		tss, err := store.InsertEvents(inserted.sourceActorID, nil, []*proto.Event{inserted.event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = store.UpdateEventStatus(tss[0], EventRecordStatusAccepted, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := VerifyReplay(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validation := NewValidation()
+
+	// Root kept its role, the legacy ones count as none.
+	results, err := InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{permission(3, PermissionScenarist, false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Status != EventRecordStatusAccepted {
+		t.Errorf("grant by root after the replay: %+v", results[0])
+	}
+
+	for actorID, want := range map[int64]Capability{1: CapabilityPlay, 2: CapabilityPlay} {
+		if capabilities, err := validation.ActorCapabilities(store, actorID); err != nil || capabilities != want {
+			t.Errorf("actor %d has capabilities %b, want %b, %v", actorID, capabilities, want, err)
+		}
+	}
+
+	// The submissions are held to the current rules.
+	results, err = InsertAndCheckEvents(store, validation, -1, 2, nil, []*proto.Event{{Msg: &proto.Event_Reset_{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Status != EventRecordStatusRejected {
+		t.Errorf("reset by a player: %+v", results[0])
+	}
+}
+
+func TestOrgaEventsFollowCapabilities(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if results[0].Status != EventRecordStatusAccepted {
+			t.Fatalf("%v: %+v", event, results[0])
+		}
+	}
+
+	insert(1, seedActor("benoit"))
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(0, permission(1, PermissionOrga, false))
+	insert(0, permission(3, PermissionOrgaReadOnly, false))
+	insert(1, &proto.Event{Msg: &proto.Event_Group{Group: &proto.EventGroup{GroupId: "group:1", Name: "Guild", Capacity: 4}}})
+
+	types := func() map[string]int {
+		t.Helper()
+
+		events, err := FetchEvents(store, NewProjections(), 1, ActorSpaceOrga, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		types := map[string]int{}
+
+		for _, event := range events {
+			types[fmt.Sprintf("%T", event.Msg)]++
+		}
+
+		return types
+	}
+
+	// The orga sees the roles of the other actors.
+	if got := types(); got["*proto.Event_Permission"] != 2 || got["*proto.Event_SeedPlayer"] != 1 || got["*proto.Event_SeedActor"] != 2 {
+		t.Errorf("orga gets %v", got)
+	}
+
+	insert(0, permission(1, PermissionOrga, true))
+
+	// Once revoked, only the events about itself are left.
+	want := map[string]int{"*proto.Event_SeedActor": 1, "*proto.Event_Permission": 2}
+	if diff := cmp.Diff(want, types()); diff != "" {
+		t.Errorf("revoked orga gets (-want +got):\n%s", diff)
+	}
+}
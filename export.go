@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/jmoiron/sqlx"
+	"google.golang.org/protobuf/encoding/protojson"
+	protolib "google.golang.org/protobuf/proto"
+)
+
+// maxExportLineSize bounds the size of a single exported record.
+const maxExportLineSize = 16 << 20
+
+func writeExportRecord(w io.Writer, record *proto.ExportRecord) error {
+	data, err := protojson.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("protojson marshal: %w", err)
+	}
+
+	// protojson output is not stable across runs, compacting it keeps
+	// exports of the same database byte for byte identical.
+	var line bytes.Buffer
+
+	err = json.Compact(&line, data)
+	if err != nil {
+		return fmt.Errorf("compact: %w", err)
+	}
+
+	line.WriteByte('\n')
+
+	_, err = w.Write(line.Bytes())
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+// Export writes the actors, public keys, auth keys, batches and events of db
+// to w as newline delimited protojson ExportRecord.
+func Export(db *sqlx.DB, w io.Writer) error {
+	ctx := context.Background()
+
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("conn: %w", err)
+	}
+
+	defer conn.Close()
+
+	// The transactions of db are immediate, a deferred one reads a single
+	// snapshot of the database without holding its write lock.
+	_, err = conn.ExecContext(ctx, `BEGIN`)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer conn.ExecContext(ctx, `ROLLBACK`)
+
+	rows, err := conn.QueryxContext(ctx, `SELECT id, space FROM actors ORDER BY id ASC`)
+	if err != nil {
+		return fmt.Errorf("query actors: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var actor proto.ExportActor
+
+		err = rows.Scan(&actor.Id, &actor.Space)
+		if err != nil {
+			return fmt.Errorf("scan actor: %w", err)
+		}
+
+		err = writeExportRecord(w, &proto.ExportRecord{Record: &proto.ExportRecord_Actor{Actor: &actor}})
+		if err != nil {
+			return fmt.Errorf("actor %d: %w", actor.Id, err)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read actors: %w", err)
+	}
+
+	links := map[int64][]int64{}
+
+	rows, err = conn.QueryxContext(ctx, `SELECT public_key_id, actor_id FROM actors_public_keys ORDER BY public_key_id ASC, actor_id ASC`)
+	if err != nil {
+		return fmt.Errorf("query actors_public_keys: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var publicKeyID, actorID int64
+
+		err = rows.Scan(&publicKeyID, &actorID)
+		if err != nil {
+			return fmt.Errorf("scan actors_public_keys: %w", err)
+		}
+
+		links[publicKeyID] = append(links[publicKeyID], actorID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read actors_public_keys: %w", err)
+	}
+
+	rows, err = conn.QueryxContext(ctx, `SELECT id, public_key FROM public_keys ORDER BY id ASC`)
+	if err != nil {
+		return fmt.Errorf("query public keys: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var publicKey proto.ExportPublicKey
+
+		err = rows.Scan(&publicKey.Id, &publicKey.PublicKey)
+		if err != nil {
+			return fmt.Errorf("scan public key: %w", err)
+		}
+
+		publicKey.ActorIds = links[publicKey.Id]
+
+		err = writeExportRecord(w, &proto.ExportRecord{Record: &proto.ExportRecord_PublicKey{PublicKey: &publicKey}})
+		if err != nil {
+			return fmt.Errorf("public key %d: %w", publicKey.Id, err)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read public keys: %w", err)
+	}
+
+	rows, err = conn.QueryxContext(ctx, `SELECT key, actor_id, COALESCE(redeemed_at, 0) FROM auth_keys ORDER BY key ASC`)
+	if err != nil {
+		return fmt.Errorf("query auth keys: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var authKey proto.ExportAuthKey
+
+		err = rows.Scan(&authKey.Key, &authKey.ActorId, &authKey.RedeemedAt)
+		if err != nil {
+			return fmt.Errorf("scan auth key: %w", err)
+		}
+
+		err = writeExportRecord(w, &proto.ExportRecord{Record: &proto.ExportRecord_AuthKey{AuthKey: &authKey}})
+		if err != nil {
+			return fmt.Errorf("auth key: %w", err)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read auth keys: %w", err)
+	}
+
+	rows, err = conn.QueryxContext(ctx, `SELECT id, public_key_id, token, payload, digests FROM batches ORDER BY id ASC`)
+	if err != nil {
+		return fmt.Errorf("query batches: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var batch proto.ExportBatch
+
+		err = rows.Scan(&batch.Id, &batch.PublicKeyId, &batch.Token, &batch.Payload, &batch.Digests)
+		if err != nil {
+			return fmt.Errorf("scan batch: %w", err)
+		}
+
+		err = writeExportRecord(w, &proto.ExportRecord{Record: &proto.ExportRecord_Batch{Batch: &batch}})
+		if err != nil {
+			return fmt.Errorf("batch %d: %w", batch.Id, err)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read batches: %w", err)
+	}
+
+	rows, err = conn.QueryxContext(ctx, `
+	SELECT
+	  events.ts,
+	  events.source_actor_id,
+	  events.data,
+	  events.status,
+	  COALESCE(events_stuttering.reason, ''),
+	  COALESCE(events.batch_id, 0),
+	  COALESCE(events.batch_index, 0),
+	  events.hash
+	FROM events
+	LEFT JOIN events_stuttering ON events_stuttering.ts = events.ts
+	ORDER BY events.ts ASC`)
+	if err != nil {
+		return fmt.Errorf("query events: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var event proto.ExportEvent
+		var data []byte
+		var status EventRecordStatus
+
+		err = rows.Scan(&event.Ts, &event.SourceActorId, &data, &status, &event.Reason, &event.BatchId, &event.BatchIndex, &event.Hash)
+		if err != nil {
+			return fmt.Errorf("scan event: %w", err)
+		}
+
+		event.Status = status.String()
+		event.Event = &proto.Event{}
+
+		err = protolib.Unmarshal(data, event.Event)
+		if err != nil {
+			return fmt.Errorf("event %d: proto unmarshall: %w", event.Ts, err)
+		}
+
+		err = writeExportRecord(w, &proto.ExportRecord{Record: &proto.ExportRecord_Event{Event: &event}})
+		if err != nil {
+			return fmt.Errorf("event %d: %w", event.Ts, err)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read events: %w", err)
+	}
+
+	rows, err = conn.QueryxContext(ctx, `SELECT ts, data_hash, head, hash FROM events_rewrites ORDER BY id ASC`)
+	if err != nil {
+		return fmt.Errorf("query rewrites: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rewrite proto.ExportRewrite
+
+		err = rows.Scan(&rewrite.Ts, &rewrite.DataHash, &rewrite.Head, &rewrite.Hash)
+		if err != nil {
+			return fmt.Errorf("scan rewrite: %w", err)
+		}
+
+		err = writeExportRecord(w, &proto.ExportRecord{Record: &proto.ExportRecord_Rewrite{Rewrite: &rewrite}})
+		if err != nil {
+			return fmt.Errorf("rewrite of event %d: %w", rewrite.Ts, err)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("read rewrites: %w", err)
+	}
+
+	return nil
+}
+
+// Import loads an export produced by Export into db, which must not hold any
+// event or actor yet. Everything is imported in a single transaction. The
+// events keep their exported hashes and rewrites, the import is rejected
+// when they no longer verify against the imported data.
+func Import(db *sqlx.DB, r io.Reader) error {
+	var events, actors int
+
+	err := db.QueryRowx(`SELECT (SELECT COUNT(*) FROM events), (SELECT COUNT(*) FROM actors WHERE id != 0)`).Scan(&events, &actors)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if events > 0 || actors > 0 {
+		return fmt.Errorf("database is not empty: %d events, %d actors", events, actors)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExportLineSize)
+
+	var line int
+
+	for scanner.Scan() {
+		line++
+
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var record proto.ExportRecord
+
+		err = protojson.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return fmt.Errorf("line %d: protojson unmarshal: %w", line, err)
+		}
+
+		err = importRecord(tx, &record)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+
+	_, err = VerifyChain(&SQLiteStore{db: db, tx: tx, sealed: true})
+	if err != nil {
+		return fmt.Errorf("verify chain: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+func importRecord(tx *sqlx.Tx, record *proto.ExportRecord) error {
+	switch v := record.Record.(type) {
+	case *proto.ExportRecord_Actor:
+		_, err := tx.Exec(
+			`INSERT INTO actors (id, space) VALUES (?, ?) ON CONFLICT(id) DO UPDATE SET space=excluded.space`,
+			v.Actor.Id,
+			v.Actor.Space,
+		)
+		if err != nil {
+			return fmt.Errorf("insert actor: %w", err)
+		}
+	case *proto.ExportRecord_PublicKey:
+		_, err := tx.Exec(`INSERT INTO public_keys (id, public_key) VALUES (?, ?)`, v.PublicKey.Id, v.PublicKey.PublicKey)
+		if err != nil {
+			return fmt.Errorf("insert public key: %w", err)
+		}
+
+		for _, actorID := range v.PublicKey.ActorIds {
+			_, err = tx.Exec(`INSERT INTO actors_public_keys (actor_id, public_key_id) VALUES (?, ?)`, actorID, v.PublicKey.Id)
+			if err != nil {
+				return fmt.Errorf("insert actors_public_keys: %w", err)
+			}
+		}
+	case *proto.ExportRecord_AuthKey:
+		redeemedAt := sql.NullInt64{Int64: v.AuthKey.RedeemedAt, Valid: v.AuthKey.RedeemedAt != 0}
+
+		_, err := tx.Exec(`INSERT INTO auth_keys (key, actor_id, redeemed_at) VALUES (?, ?, ?)`, v.AuthKey.Key, v.AuthKey.ActorId, redeemedAt)
+		if err != nil {
+			return fmt.Errorf("insert auth key: %w", err)
+		}
+	case *proto.ExportRecord_Batch:
+		_, err := tx.Exec(
+			`INSERT INTO batches (id, public_key_id, token, payload, digests) VALUES (?, ?, ?, ?, ?)`,
+			v.Batch.Id,
+			v.Batch.PublicKeyId,
+			v.Batch.Token,
+			v.Batch.Payload,
+			v.Batch.Digests,
+		)
+		if err != nil {
+			return fmt.Errorf("insert batch: %w", err)
+		}
+	case *proto.ExportRecord_Event:
+		status, err := ParseEventRecordStatus(v.Event.Status)
+		if err != nil {
+			return err
+		}
+
+		if v.Event.Event == nil || v.Event.Event.Ts != v.Event.Ts {
+			return fmt.Errorf("event %d: ts does not match its payload", v.Event.Ts)
+		}
+
+		data, err := protolib.Marshal(v.Event.Event)
+		if err != nil {
+			return fmt.Errorf("marshalling event to proto: %w", err)
+		}
+
+		batchID := sql.NullInt64{Int64: v.Event.BatchId, Valid: v.Event.BatchId != 0}
+		batchIndex := sql.NullInt64{Int64: v.Event.BatchIndex, Valid: v.Event.BatchId != 0}
+
+		var hash []byte
+		if len(v.Event.Hash) > 0 {
+			hash = v.Event.Hash
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO events (ts, source_actor_id, data, status, hash, batch_id, batch_index) VALUES (?,?,?,?,?,?,?)",
+			v.Event.Ts,
+			v.Event.SourceActorId,
+			data,
+			status,
+			hash,
+			batchID,
+			batchIndex,
+		)
+		if err != nil {
+			return fmt.Errorf("insert event: %w", err)
+		}
+
+		if status == EventRecordStatusStuttering {
+			_, err = tx.Exec(`INSERT INTO events_stuttering (ts, reason) VALUES (?, ?)`, v.Event.Ts, v.Event.Reason)
+			if err != nil {
+				return fmt.Errorf("insert stuttering reason: %w", err)
+			}
+		}
+	case *proto.ExportRecord_Rewrite:
+		_, err := tx.Exec(
+			`INSERT INTO events_rewrites (ts, data_hash, head, hash) VALUES (?, ?, ?, ?)`,
+			v.Rewrite.Ts,
+			v.Rewrite.DataHash,
+			v.Rewrite.Head,
+			v.Rewrite.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("insert rewrite: %w", err)
+		}
+	default:
+		return fmt.Errorf("record %v not handled", v)
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	store := newTestStore(t)
+
+	_, _, err := store.GetState([]byte("player-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.LinkState(1, []byte("orga-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.InsertAuthKey(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.UseAuthKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.InsertAuthKey(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		playerCharacter("player:coffee-art", "character:1"),
+		seedActor("again"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.UpdateEventStatus(results[2].Ts, EventRecordStatusStuttering, "character taken")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exported bytes.Buffer
+
+	err = Export(store.db, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported := openTestStore(t)
+
+	err = Import(imported.db, bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reexported bytes.Buffer
+
+	err = Export(imported.db, &reexported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(exported.String(), reexported.String()); diff != "" {
+		t.Fatalf("export of the imported database differs (-original +imported):\n%s", diff)
+	}
+
+	_, err = VerifyChain(imported)
+	if err != nil {
+		t.Fatalf("imported events are not chained: %v", err)
+	}
+
+	stuttering, err := imported.GetStutteringEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stuttering) != 1 || stuttering[0].Reason != "character taken" {
+		t.Fatalf("unexpected stuttering events %+v", stuttering)
+	}
+
+	err = Import(imported.db, bytes.NewReader(exported.Bytes()))
+	if err == nil {
+		t.Fatal("expected an error importing into a non empty database")
+	}
+}
+
+func TestImportVerifiesChain(t *testing.T) {
+	store := openTestStore(t)
+
+	orgaID, err := store.InsertActor(ActorSpaceOrga)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submitBatch(t, store, key,
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		&proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}},
+	)
+
+	_, err = InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{permission(orgaID, PermissionOrga, false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	erasure := &proto.Event{Msg: &proto.Event_PlayerErasure{PlayerErasure: &proto.EventPlayerErasure{PlayerId: "player:coffee-art"}}}
+
+	results, err := InsertAndCheckEvents(store, NewValidation(), -1, orgaID, nil, []*proto.Event{erasure})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("erasure: %+v", results[0])
+	}
+
+	var exported bytes.Buffer
+
+	err = Export(store.db, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The batch redacted by the erasure keeps verifying once imported.
+	imported := openTestStore(t)
+
+	err = Import(imported.db, bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrites, err := imported.GetRewrites()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rewrites) == 0 {
+		t.Error("rewrites not imported")
+	}
+
+	var withoutRewrites []string
+
+	for _, line := range strings.SplitAfter(exported.String(), "\n") {
+		if !strings.Contains(line, `"Rewrite"`) {
+			withoutRewrites = append(withoutRewrites, line)
+		}
+	}
+
+	for name, export := range map[string]string{
+		"edited event":     strings.Replace(exported.String(), "art-coffee", "art-latte", 1),
+		"dropped rewrites": strings.Join(withoutRewrites, ""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if export == exported.String() {
+				t.Fatal("export not tampered")
+			}
+
+			err := Import(openTestStore(t).db, strings.NewReader(export))
+			if err == nil {
+				t.Fatal("tampered export imported")
+			}
+		})
+	}
+}
+
+func TestImportSealedWithoutKey(t *testing.T) {
+	cipher, err := NewFieldCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldCipher = cipher
+	t.Cleanup(func() { fieldCipher = nil })
+
+	store := newTestStore(t)
+
+	_, err = InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exported bytes.Buffer
+
+	err = Export(store.db, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The chain is checked on the sealed data, the key is not needed.
+	fieldCipher = nil
+
+	err = Import(openTestStore(t).db, bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+type Group struct {
+	Name              string
+	Description       string
+	Capacity          int32
+	LeaderCharacterID string
+	Members           map[string]struct{}
+}
+
+// Groups holds the groups and the characters in them. The members are the
+// ones of the current edition, the groups carry over.
+type Groups struct {
+	Groups map[string]*Group
+	// Memberships maps the characters to their group.
+	Memberships map[string]string
+}
+
+func NewGroups() Groups {
+	return Groups{
+		Groups:      map[string]*Group{},
+		Memberships: map[string]string{},
+	}
+}
+
+// normalizeGroupName folds the case and the spaces of a group name so that
+// "Guilde des Marchands" and "guilde  des marchands" are the same group.
+func normalizeGroupName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Define checks a group definition and applies it.
+func (g Groups) Define(event *proto.EventGroup) error {
+	if event.GroupId == "" {
+		return fmt.Errorf("invalid group id")
+	}
+
+	errs := FieldErrors{}
+
+	name := normalizeGroupName(event.Name)
+	if name == "" {
+		errs["name"] = "required"
+	}
+
+	for id, group := range g.Groups {
+		if id != event.GroupId && name != "" && normalizeGroupName(group.Name) == name {
+			errs["name"] = fmt.Sprintf("already used by group %q", id)
+		}
+	}
+
+	if event.Capacity < 0 {
+		errs["capacity"] = "must not be negative"
+	} else if group, exists := g.Groups[event.GroupId]; exists && event.Capacity != 0 && len(group.Members) > int(event.Capacity) {
+		errs["capacity"] = fmt.Sprintf("lower than the %d members", len(group.Members))
+	}
+
+	if event.LeaderCharacterId != "" && g.Memberships[event.LeaderCharacterId] != event.GroupId {
+		errs["leaderCharacterId"] = "not a member of the group"
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	g.apply(event)
+
+	return nil
+}
+
+func (g Groups) apply(event *proto.EventGroup) {
+	group, exists := g.Groups[event.GroupId]
+	if !exists {
+		group = &Group{Members: map[string]struct{}{}}
+		g.Groups[event.GroupId] = group
+	}
+
+	group.Name = strings.Join(strings.Fields(event.Name), " ")
+	group.Description = event.Description
+	group.Capacity = event.Capacity
+	group.LeaderCharacterID = event.LeaderCharacterId
+}
+
+// Join checks that characterID may move to groupID, an empty groupID leaves
+// its group, and moves it.
+func (g Groups) Join(characterID, groupID string) error {
+	current := g.Memberships[characterID]
+	if current == groupID {
+		return nil
+	}
+
+	if groupID != "" {
+		group, exists := g.Groups[groupID]
+		if !exists {
+			return FieldErrors{"groupId": "unknown group"}
+		}
+
+		if group.Capacity != 0 && len(group.Members) >= int(group.Capacity) {
+			return FieldErrors{"groupId": fmt.Sprintf("group %q is full", group.Name)}
+		}
+	}
+
+	if current != "" && g.Groups[current].LeaderCharacterID == characterID {
+		return FieldErrors{"groupId": fmt.Sprintf("character leads group %q", g.Groups[current].Name)}
+	}
+
+	g.move(characterID, groupID)
+
+	return nil
+}
+
+func (g Groups) move(characterID, groupID string) {
+	if current, exists := g.Memberships[characterID]; exists {
+		delete(g.Groups[current].Members, characterID)
+		delete(g.Memberships, characterID)
+	}
+
+	if groupID == "" {
+		return
+	}
+
+	if group, exists := g.Groups[groupID]; exists {
+		group.Members[characterID] = struct{}{}
+		g.Memberships[characterID] = groupID
+	}
+}
+
+// resetMembers empties the groups, the characters of a new edition join them
+// again.
+func (g Groups) resetMembers() {
+	clear(g.Memberships)
+
+	for _, group := range g.Groups {
+		clear(group.Members)
+	}
+}
+
+type GroupMembership struct {
+	GroupID           string   `json:"groupId"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Capacity          int32    `json:"capacity"`
+	LeaderCharacterID string   `json:"leaderCharacterId,omitempty"`
+	Members           []string `json:"members"`
+}
+
+// GroupMemberships returns the groups of the accepted events of store, sorted
+// by name, with the characters in them in the current edition.
+func (v *Validation) GroupMemberships(store EventStore) ([]GroupMembership, error) {
+	var memberships []GroupMembership
+
+	err := v.read(store, func(view *validationView) {
+		memberships = view.groups
+	})
+
+	return memberships, err
+}
+
+func (g Groups) list() []GroupMembership {
+	memberships := make([]GroupMembership, 0, len(g.Groups))
+
+	for id, group := range g.Groups {
+		members := make([]string, 0, len(group.Members))
+		for characterID := range group.Members {
+			members = append(members, characterID)
+		}
+
+		slices.Sort(members)
+
+		memberships = append(memberships, GroupMembership{
+			GroupID:           id,
+			Name:              group.Name,
+			Description:       group.Description,
+			Capacity:          group.Capacity,
+			LeaderCharacterID: group.LeaderCharacterID,
+			Members:           members,
+		})
+	}
+
+	slices.SortFunc(memberships, func(a, b GroupMembership) int {
+		return strings.Compare(normalizeGroupName(a.Name), normalizeGroupName(b.Name))
+	})
+
+	return memberships
+}
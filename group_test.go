@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func group(groupID, name string, capacity int32, leaderCharacterID string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_Group{Group: &proto.EventGroup{GroupId: groupID, Name: name, Capacity: capacity, LeaderCharacterId: leaderCharacterID}}}
+}
+
+func memberOf(playerID, characterID, groupID string) *proto.Event {
+	event := playerCharacter(playerID, characterID)
+	event.GetPlayerCharacter().GroupId = groupID
+
+	return event
+}
+
+func TestGroups(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(0, permission(1, PermissionOrga, false))
+
+	for i, test := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+		fields        FieldErrors
+		accepted      bool
+	}{
+		{2, group("merchants", "Guilde des Marchands", 1, ""), nil, false},
+		{1, group("merchants", "Guilde des Marchands", 1, ""), nil, true},
+		{1, group("guild", " guilde  des marchands", 0, ""), FieldErrors{"name": `already used by group "merchants"`}, false},
+		{1, group("thieves", "", -1, ""), FieldErrors{"name": "required", "capacity": "must not be negative"}, false},
+		{1, group("thieves", "Voleurs", 0, ""), nil, true},
+		{0, group("thieves", "Voleurs", 0, ""), nil, true},
+		{2, memberOf("player:coffee-art", "character:1", "pirates"), FieldErrors{"groupId": "unknown group"}, false},
+		{2, memberOf("player:coffee-art", "character:1", "merchants"), nil, true},
+		{2, memberOf("player:coffee-art", "character:2", "merchants"), FieldErrors{"groupId": `group "Guilde des Marchands" is full`}, false},
+		{1, group("merchants", "Guilde des Marchands", 1, "character:2"), FieldErrors{"leaderCharacterId": "not a member of the group"}, false},
+		{1, group("merchants", "Guilde des Marchands", 2, "character:1"), nil, true},
+		{2, memberOf("player:coffee-art", "character:2", "merchants"), nil, true},
+		{1, group("merchants", "Guilde des Marchands", 1, "character:1"), FieldErrors{"capacity": "lower than the 2 members"}, false},
+		{2, memberOf("player:coffee-art", "character:1", "thieves"), FieldErrors{"groupId": `character leads group "Guilde des Marchands"`}, false},
+		{2, memberOf("player:coffee-art", "character:2", "thieves"), nil, true},
+	} {
+		result := insert(test.sourceActorID, test.event)
+		if (result.Status == EventRecordStatusAccepted) != test.accepted {
+			t.Errorf("#%d: accepted %v, got %+v", i, test.accepted, result)
+		}
+
+		if diff := cmp.Diff(test.fields, result.Fields); diff != "" {
+			t.Errorf("#%d: field errors (-want +got):\n%s", i, diff)
+		}
+	}
+
+	want := []GroupMembership{
+		{GroupID: "merchants", Name: "Guilde des Marchands", Capacity: 2, LeaderCharacterID: "character:1", Members: []string{"character:1"}},
+		{GroupID: "thieves", Name: "Voleurs", Members: []string{"character:2"}},
+	}
+
+	for _, validation := range []*Validation{validation, NewValidation()} {
+		memberships, err := validation.GroupMemberships(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(want, memberships); diff != "" {
+			t.Errorf("memberships (-want +got):\n%s", diff)
+		}
+	}
+
+	events, err := FetchEvents(store, NewProjections(), 2, ActorSpacePlayer, "", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var groups int
+
+	for _, event := range events {
+		if event.GetGroup() != nil {
+			groups++
+		}
+	}
+
+	if groups != 4 {
+		t.Errorf("player gets %d group definitions, want 4", groups)
+	}
+
+	insert(0, &proto.Event{Msg: &proto.Event_Edition{Edition: &proto.EventEdition{Name: "2027"}}})
+
+	memberships, err := validation.GroupMemberships(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, membership := range memberships {
+		if len(membership.Members) != 0 {
+			t.Errorf("group %q keeps members %v in a new edition", membership.GroupID, membership.Members)
+		}
+	}
+}
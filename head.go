@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// LoadHeadKey reads the PEM encoded PKCS #8 P-256 private key at path, the
+// key signing the head of the log returned with the fetched events.
+func LoadHeadKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse key: %w", err)
+	}
+
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok || privateKey.Curve != elliptic.P256() {
+		return nil, errors.New("not a P-256 key")
+	}
+
+	return privateKey, nil
+}
+
+// headMessage returns what the signature of a head covers: the big endian
+// ts of the last event followed by the hash.
+func headMessage(ts int64, head []byte) []byte {
+	return append(binary.BigEndian.AppendUint64(nil, uint64(ts)), head...)
+}
+
+// SignHead signs the head of the log with key. The signature is the ES256
+// one, r and s as 32 bytes each, that WebCrypto verifies.
+func SignHead(key *ecdsa.PrivateKey, ts int64, head []byte) ([]byte, error) {
+	sum := sha256.Sum256(headMessage(ts, head))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signature, nil
+}
+
+// VerifyHead reports whether signature is the one of the head by the key of
+// publicKey.
+func VerifyHead(publicKey *ecdsa.PublicKey, ts int64, head []byte, signature []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+
+	sum := sha256.Sum256(headMessage(ts, head))
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+
+	return ecdsa.Verify(publicKey, sum[:], r, s)
+}
+
+// HeadKeyJWK returns the public key of key as the JWK the clients are
+// configured with to check the head.
+func HeadKeyJWK(key *ecdsa.PrivateKey) (string, error) {
+	public, err := key.PublicKey.ECDH()
+	if err != nil {
+		return "", fmt.Errorf("public key: %w", err)
+	}
+
+	// The uncompressed point, 0x04 then x and y.
+	point := public.Bytes()
+
+	data, err := json.Marshal(map[string]string{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+	})
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"crypto/ecdsa"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -14,9 +15,9 @@ import (
 
 	"github.com/ebenaum/thekeeper/proto"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/jmoiron/sqlx"
 	"github.com/lestrrat-go/jwx/jwk"
 	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/protobuf/encoding/protojson"
 	protolib "google.golang.org/protobuf/proto"
 )
 
@@ -29,10 +30,14 @@ func (e Error) Error() string {
 	return e.Private.Error()
 }
 
-func validatePublicKey(tokenString string) (ecdsa.PublicKey, error) {
+// parseToken checks the ES256 signature of a token against the public key
+// embedded in its header and returns that key with the token claims.
+func parseToken(tokenString string, options ...jwt.ParserOption) (ecdsa.PublicKey, jwt.MapClaims, error) {
 	var publicKey ecdsa.PublicKey
 
-	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		jwkJSON, err := json.Marshal(token.Header["jwk"])
 		if err != nil {
 			return nil, Error{errors.New("invalid public key"), fmt.Errorf("json marshal: %w", err)}
@@ -56,21 +61,50 @@ func validatePublicKey(tokenString string) (ecdsa.PublicKey, error) {
 		return &publicKey, nil
 
 	},
-		jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}),
+		append([]jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()})}, options...)...,
+	)
+	if err != nil {
+		return publicKey, claims, err
+	}
+
+	return publicKey, claims, nil
+}
+
+func validateToken(tokenString string) (ecdsa.PublicKey, jwt.MapClaims, error) {
+	return parseToken(tokenString,
 		jwt.WithIssuedAt(),
 		jwt.WithAudience("thekeeper"),
 		jwt.WithExpirationRequired(),
 		jwt.WithLeeway(time.Second*30),
 		jwt.WithIssuer("self"),
 	)
+}
+
+func validatePublicKey(tokenString string) (ecdsa.PublicKey, error) {
+	publicKey, _, err := validateToken(tokenString)
+
+	return publicKey, err
+}
+
+func publicKeyBytes(publicKey ecdsa.PublicKey) []byte {
+	return append(publicKey.X.Bytes(), publicKey.Y.Bytes()...)
+}
+
+// checkActive rejects the deactivated actors.
+func checkActive(store Store, validation *Validation, actorID int64) error {
+	deactivated, err := validation.ActorDeactivated(store, actorID)
 	if err != nil {
-		return publicKey, err
+		return Error{errors.New("internal error"), fmt.Errorf("actor deactivated: %w", err)}
+	}
+
+	if deactivated {
+		return Error{errors.New("actor deactivated"), fmt.Errorf("actor %d is deactivated", actorID)}
 	}
 
-	return publicKey, nil
+	return nil
 }
 
-func auth(db *sqlx.DB, tokenString string) (int64, ActorSpace, error) {
+func auth(store Store, validation *Validation, tokenString string) (int64, ActorSpace, error) {
 	var actorID int64
 	var actorSpace ActorSpace
 
@@ -79,21 +113,59 @@ func auth(db *sqlx.DB, tokenString string) (int64, ActorSpace, error) {
 		return actorID, actorSpace, err
 	}
 
-	actorID, actorSpace, err = GetState(db, append(publicKey.X.Bytes(), publicKey.Y.Bytes()...))
+	actorID, actorSpace, err = store.GetState(publicKeyBytes(publicKey))
 	if err != nil {
 		return actorID, actorSpace, Error{errors.New("invalid public key"), fmt.Errorf("get state: %w", err)}
 	}
 
-	log.Printf("%d %q %s", actorID, actorSpace, hex.EncodeToString(append(publicKey.X.Bytes(), publicKey.Y.Bytes()...)))
+	log.Printf("%d %q %s", actorID, actorSpace, hex.EncodeToString(publicKeyBytes(publicKey)))
+
+	return actorID, actorSpace, checkActive(store, validation, actorID)
+}
+
+// authBatch authenticates the submission of payload. The token must sign the
+// hash of payload, the returned batch keeps both as proof of authorship.
+func authBatch(store Store, validation *Validation, tokenString string, payload []byte) (int64, *Batch, error) {
+	publicKey, claims, err := validateToken(tokenString)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	payloadHash, _ := claims[PayloadHashClaim].(string)
+	if payloadHash != PayloadHash(payload) {
+		return -1, nil, Error{errors.New("invalid payload signature"), fmt.Errorf("%s claim %q does not match the payload", PayloadHashClaim, payloadHash)}
+	}
+
+	digests, err := EventDigests(payload)
+	if err != nil {
+		return -1, nil, Error{errors.New("invalid payload"), err}
+	}
+
+	eventsHash, _ := claims[EventsHashClaim].(string)
+	if eventsHash != EventsHash(digests) {
+		return -1, nil, Error{errors.New("invalid payload signature"), fmt.Errorf("%s claim %q does not match the events of the payload", EventsHashClaim, eventsHash)}
+	}
+
+	actorID, actorSpace, err := store.GetState(publicKeyBytes(publicKey))
+	if err != nil {
+		return -1, nil, Error{errors.New("invalid public key"), fmt.Errorf("get state: %w", err)}
+	}
+
+	log.Printf("%d %q %s", actorID, actorSpace, hex.EncodeToString(publicKeyBytes(publicKey)))
+
+	err = checkActive(store, validation, actorID)
+	if err != nil {
+		return -1, nil, err
+	}
 
-	return actorID, actorSpace, err
+	return actorID, &Batch{PublicKey: publicKeyBytes(publicKey), Token: tokenString, Payload: payload}, nil
 }
 
-func HandleState(db *sqlx.DB) http.HandlerFunc {
+func HandleState(store Store, validation *Validation, projections *Projections) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if r.Method != http.MethodGet && r.Method != http.MethodOptions {
-			POSTState(db)(w, r)
+			POSTState(store, validation)(w, r)
 
 			return
 		}
@@ -107,7 +179,7 @@ func HandleState(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		actorID, space, err := auth(db, r.Header.Get("Authorization"))
+		actorID, space, err := auth(store, validation, r.Header.Get("Authorization"))
 		if err != nil {
 			var errplus Error
 
@@ -139,7 +211,25 @@ func HandleState(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		events, err := FetchEvents(db, actorID, space, from)
+		edition := r.URL.Query().Get("edition")
+
+		if edition != "" && space != ActorSpaceOrga {
+			w.WriteHeader(http.StatusBadRequest)
+
+			log.Printf("actor %d space:%s not authorized to query edition %q", actorID, space, edition)
+			fmt.Fprintf(w, `{"message": "not authorized"}`)
+
+			return
+		}
+
+		events, err := FetchEvents(store, projections, actorID, space, edition, from)
+		if errors.Is(err, ErrEditionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+
+			fmt.Fprintf(w, `{"message": "edition not found"}`)
+
+			return
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 
@@ -151,7 +241,28 @@ func HandleState(db *sqlx.DB) http.HandlerFunc {
 		log.Printf("%d events", len(events))
 
 		response := &proto.Events{
-			Events: events,
+			Events:  events,
+			ActorId: actorID,
+		}
+
+		if validation.HeadKey != nil {
+			response.HeadTs, response.Head, err = store.GetHead()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				log.Println(err)
+
+				return
+			}
+
+			response.HeadSignature, err = SignHead(validation.HeadKey, response.HeadTs, response.Head)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				log.Println(err)
+
+				return
+			}
 		}
 
 		responseEncoded, err := protolib.Marshal(response)
@@ -177,7 +288,7 @@ func HandleState(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
-func HandleCreateAuthKey(db *sqlx.DB) http.HandlerFunc {
+func HandleCreateAuthKey(store Store, validation *Validation) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost && r.Method != http.MethodOptions {
 			w.WriteHeader(http.StatusNotFound)
@@ -194,7 +305,7 @@ func HandleCreateAuthKey(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		actorID, actorSpace, err := auth(db, r.Header.Get("Authorization"))
+		actorID, actorSpace, err := auth(store, validation, r.Header.Get("Authorization"))
 		if err != nil {
 			var errplus Error
 
@@ -213,16 +324,16 @@ func HandleCreateAuthKey(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		if actorSpace != ActorSpaceOrga {
-			w.WriteHeader(http.StatusBadRequest)
-
-			log.Printf("actor %d space:%s not authorized to create auth link", actorID, actorSpace)
-			fmt.Fprintf(w, `{"message": "not authorized"}`)
+		capabilities, err := validation.ActorCapabilities(store, actorID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Println(err)
+			fmt.Fprintf(w, `{"message": "internal error"}`)
 
 			return
 		}
 
-		if actorSpace != ActorSpaceOrga {
+		if !capabilities.Has(CapabilityLinkPlayers) {
 			w.WriteHeader(http.StatusBadRequest)
 
 			log.Printf("actor %d space:%s not authorized to create auth link", actorID, actorSpace)
@@ -233,14 +344,14 @@ func HandleCreateAuthKey(db *sqlx.DB) http.HandlerFunc {
 
 		handleToLink := r.PathValue("handle")
 
-		actorIDToLink, err := FindActorIDByHandle(db, handleToLink)
+		actorIDToLink, err := validation.FindActorIDByHandle(store, handleToLink)
 		if err != nil {
 			w.WriteHeader(http.StatusNotFound)
 
 			return
 		}
 
-		actorSpaceToLink, err := GetActorSpaceByActorID(db, actorIDToLink)
+		actorSpaceToLink, err := store.GetActorSpaceByActorID(actorIDToLink)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			log.Println(err)
@@ -258,7 +369,7 @@ func HandleCreateAuthKey(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		authKey, err := InsertAuthKey(db, actorIDToLink)
+		authKey, err := store.InsertAuthKey(actorIDToLink)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 
@@ -271,7 +382,7 @@ func HandleCreateAuthKey(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
-func HandleRedeemAuthKey(db *sqlx.DB) http.HandlerFunc {
+func HandleRedeemAuthKey(store Store, validation *Validation) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost && r.Method != http.MethodOptions {
 			w.WriteHeader(http.StatusNotFound)
@@ -307,7 +418,7 @@ func HandleRedeemAuthKey(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		actorID, err := UseAuthKey(db, r.PathValue("key"))
+		actorID, err := store.UseAuthKey(r.PathValue("key"))
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 
@@ -316,7 +427,17 @@ func HandleRedeemAuthKey(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		_, err = LinkState(db, actorID, append(publicKey.X.Bytes(), publicKey.Y.Bytes()...))
+		err = checkActive(store, validation, actorID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			log.Println(err)
+			fmt.Fprintf(w, `{"message": "actor deactivated"}`)
+
+			return
+		}
+
+		_, err = store.LinkState(actorID, publicKeyBytes(publicKey))
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 
@@ -327,7 +448,7 @@ func HandleRedeemAuthKey(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
-func POSTState(db *sqlx.DB) http.HandlerFunc {
+func POSTState(store Store, validation *Validation) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusNotFound)
@@ -338,8 +459,20 @@ func POSTState(db *sqlx.DB) http.HandlerFunc {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Headers", "Authorization")
 
+		var eventsRequests proto.Events
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			log.Println(err)
+			fmt.Fprint(w, `{"message": "bad input"}`)
+
+			return
+		}
+
 		start := time.Now()
-		actorID, _, err := auth(db, r.Header.Get("Authorization"))
+		actorID, batch, err := authBatch(store, validation, r.Header.Get("Authorization"), body)
 		log.Printf("AUTH %v", time.Since(start))
 		if err != nil {
 			var errplus Error
@@ -364,9 +497,7 @@ func POSTState(db *sqlx.DB) http.HandlerFunc {
 			log.Printf("APP POST %v", time.Since(start))
 		}()
 
-		var eventsRequests proto.Events
-
-		body, err := io.ReadAll(r.Body)
+		err = protolib.Unmarshal(body, &eventsRequests)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 
@@ -376,37 +507,296 @@ func POSTState(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
-		err = protolib.Unmarshal(body, &eventsRequests)
+		batch.IdempotencyKey = eventsRequests.IdempotencyKey
+
+		if len(eventsRequests.Events) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+
+			fmt.Fprint(w, `{"message": "bad input"}`)
+
+			return
+		}
+
+		result, err := InsertAndCheckEvents(store, validation, -1, actorID, batch, eventsRequests.Events)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 
 			log.Println(err)
-			fmt.Fprint(w, `{"message": "bad input"}`)
+			fmt.Fprintf(w, `{"message": "%s"}`, err.Error())
 
 			return
 		}
 
-		if len(eventsRequests.Events) == 0 {
+		encoder := json.NewEncoder(w)
+		err = encoder.Encode(result)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+	}
+}
+
+type stutteringEventResponse struct {
+	Ts            int64           `json:"ts"`
+	SourceActorID int64           `json:"sourceActorId"`
+	Reason        string          `json:"reason"`
+	Event         json.RawMessage `json:"event"`
+}
+
+func HandleStutteringEvents(store Store, validation *Validation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodOptions {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		actorID, actorSpace, err := auth(store, validation, r.Header.Get("Authorization"))
+		if err != nil {
+			var errplus Error
+
 			w.WriteHeader(http.StatusBadRequest)
 
-			fmt.Fprint(w, `{"message": "bad input"}`)
+			if errors.As(err, &errplus) {
+				log.Println(errplus.Private)
+				fmt.Fprintf(w, `{"message": "%s"}`, errplus.Public.Error())
+
+				return
+			}
+
+			log.Println(err)
+			fmt.Fprintf(w, `{"message": "%s"}`, err.Error())
 
 			return
 		}
 
-		result, err := InsertAndCheckEvents(db, -1, actorID, eventsRequests.Events)
+		capabilities, err := validation.ActorCapabilities(store, actorID)
 		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+
+		if actorSpace != ActorSpaceOrga || (!capabilities.Has(CapabilityReadPlayers) && !capabilities.Has(CapabilityReadCharacters)) {
 			w.WriteHeader(http.StatusBadRequest)
 
+			log.Printf("actor %d space:%s not authorized to list stuttering events", actorID, actorSpace)
+			fmt.Fprintf(w, `{"message": "not authorized"}`)
+
+			return
+		}
+
+		records, err := store.GetStutteringEvents()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+
+		response := make([]stutteringEventResponse, 0, len(records))
+
+		for _, record := range records {
+			if read, _ := EventCapabilities(record.Event); !capabilities.Has(read) {
+				continue
+			}
+
+			if record.Event.GetPlayerPerson() != nil && !capabilities.Has(CapabilityReadMedical) {
+				record.Event = redactMedical(record.Event)
+			}
+
+			event, err := protojson.Marshal(record.Event)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				log.Println(err)
+
+				return
+			}
+
+			response = append(response, stutteringEventResponse{
+				Ts:            record.Event.Ts,
+				SourceActorID: record.SourceActorID,
+				Reason:        record.Reason,
+				Event:         event,
+			})
+		}
+
+		encoder := json.NewEncoder(w)
+		err = encoder.Encode(response)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+	}
+}
+
+// HandleGroups lists the groups with their members to the orgas able to read
+// the characters.
+func HandleGroups(store Store, validation *Validation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodOptions {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		actorID, actorSpace, err := auth(store, validation, r.Header.Get("Authorization"))
+		if err != nil {
+			var errplus Error
+
+			w.WriteHeader(http.StatusBadRequest)
+
+			if errors.As(err, &errplus) {
+				log.Println(errplus.Private)
+				fmt.Fprintf(w, `{"message": "%s"}`, errplus.Public.Error())
+
+				return
+			}
+
 			log.Println(err)
 			fmt.Fprintf(w, `{"message": "%s"}`, err.Error())
 
 			return
 		}
 
+		capabilities, err := validation.ActorCapabilities(store, actorID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+
+		if actorSpace != ActorSpaceOrga || !capabilities.Has(CapabilityReadCharacters) {
+			w.WriteHeader(http.StatusBadRequest)
+
+			log.Printf("actor %d space:%s not authorized to list groups", actorID, actorSpace)
+			fmt.Fprintf(w, `{"message": "not authorized"}`)
+
+			return
+		}
+
+		memberships, err := validation.GroupMemberships(store)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+
 		encoder := json.NewEncoder(w)
-		err = encoder.Encode(result)
+		err = encoder.Encode(memberships)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Println(err)
+
+			return
+		}
+	}
+}
+
+func HandleResolveStutteringEvent(store Store, validation *Validation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodOptions {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		actorID, actorSpace, err := auth(store, validation, r.Header.Get("Authorization"))
+		if err != nil {
+			var errplus Error
+
+			w.WriteHeader(http.StatusBadRequest)
+
+			if errors.As(err, &errplus) {
+				log.Println(errplus.Private)
+				fmt.Fprintf(w, `{"message": "%s"}`, errplus.Public.Error())
+
+				return
+			}
+
+			log.Println(err)
+			fmt.Fprintf(w, `{"message": "%s"}`, err.Error())
+
+			return
+		}
+
+		ts, err := strconv.ParseInt(r.PathValue("ts"), 10, 64)
 		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			fmt.Fprint(w, `{"message": "bad input"}`)
+
+			return
+		}
+
+		err = validation.ResolveStutteringEvent(store, actorID, ts, func(record StutteringEventRecord, capabilities Capability) error {
+			if _, edit := EventCapabilities(record.Event); actorSpace != ActorSpaceOrga || !capabilities.Has(edit) {
+				return Error{errors.New("not authorized"), fmt.Errorf("actor %d space:%s not authorized to resolve stuttering event %d", actorID, actorSpace, ts)}
+			}
+
+			return nil
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+
+			log.Printf("no stuttering event %d", ts)
+
+			return
+		}
+		if err != nil {
+			var errplus Error
+
+			if errors.As(err, &errplus) {
+				w.WriteHeader(http.StatusBadRequest)
+
+				log.Println(errplus.Private)
+				fmt.Fprintf(w, `{"message": "%s"}`, errplus.Public.Error())
+
+				return
+			}
+
 			w.WriteHeader(http.StatusInternalServerError)
 
 			log.Println(err)
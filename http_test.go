@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+	protolib "google.golang.org/protobuf/proto"
+)
+
+// postEvents submits request to handler as a client holding key would.
+func postEvents(t *testing.T, handler http.HandlerFunc, key *ecdsa.PrivateKey, request *proto.Events) (int, []RunEventResult) {
+	payload, err := protolib.Marshal(request)
+	if err != nil {
+		t.Error(err)
+
+		return 0, nil
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/state", bytes.NewReader(payload))
+	r.Header.Set("Authorization", signPayload(t, key, payload))
+
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	var results []RunEventResult
+
+	if w.Code == http.StatusOK {
+		err = json.Unmarshal(w.Body.Bytes(), &results)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	return w.Code, results
+}
+
+func TestParallelPOSTState(t *testing.T) {
+	for _, discardRejected := range []bool{false, true} {
+		store := openTestStore(t)
+
+		// Two servers with their own validation share the database.
+		handlers := []http.HandlerFunc{}
+
+		for range 2 {
+			validation := NewValidation()
+			validation.DiscardRejected = discardRejected
+
+			handlers = append(handlers, POSTState(store, validation))
+		}
+
+		const clients = 16
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+
+		responses := map[int64]EventRecordStatus{}
+
+		for i := range clients {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				code, results := postEvents(t, handlers[i%len(handlers)], key, &proto.Events{Events: []*proto.Event{seedActor("contested")}})
+				if code != http.StatusOK {
+					t.Errorf("client %d: status %d", i, code)
+
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				for _, result := range results {
+					responses[result.Ts] = result.Status
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		var accepted int
+
+		for _, status := range responses {
+			if status == EventRecordStatusAccepted {
+				accepted++
+			}
+		}
+
+		if accepted != 1 {
+			t.Errorf("discardRejected=%v: %d events accepted, want 1", discardRejected, accepted)
+		}
+
+		stored := storedStatuses(t, store)
+
+		for ts, status := range stored {
+			if status != responses[ts] {
+				t.Errorf("discardRejected=%v: event %d stored as %v, answered %v", discardRejected, ts, status, responses[ts])
+			}
+		}
+
+		want := clients
+		if discardRejected {
+			want = 1
+		}
+
+		if len(stored) != want {
+			t.Errorf("discardRejected=%v: %d events stored, want %d", discardRejected, len(stored), want)
+		}
+
+		err := VerifyReplay(store)
+		if err != nil {
+			t.Errorf("discardRejected=%v: %v", discardRejected, err)
+		}
+
+		_, err = VerifyChain(store)
+		if err != nil {
+			t.Errorf("discardRejected=%v: %v", discardRejected, err)
+		}
+	}
+}
+
+func TestParallelDiscardRejectedSQLite(t *testing.T) {
+	store := newTestStore(t)
+
+	// Two servers with their own validation share the database.
+	validations := []*Validation{NewValidation(), NewValidation()}
+
+	for _, validation := range validations {
+		validation.DiscardRejected = true
+	}
+
+	const batches = 16
+
+	var wg sync.WaitGroup
+
+	for i := range batches {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Each batch mixes an accepted handle with a contested one.
+			events := []*proto.Event{seedActor("handle-" + strconv.Itoa(i)), seedActor("contested")}
+
+			_, err := InsertAndCheckEvents(store, validations[i%len(validations)], -1, int64(i%3), nil, events)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, validation := range validations {
+		count, err := store.CountEvents(validation.lastTs, EventRecordStatusAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if validation.synced && count != validation.count {
+			t.Errorf("validation %d counts %d events up to %d, the log holds %d", i, validation.count, validation.lastTs, count)
+		}
+	}
+
+	stored := storedStatuses(t, store)
+
+	for ts, status := range stored {
+		if status != EventRecordStatusAccepted {
+			t.Errorf("event %d stored as %v", ts, status)
+		}
+	}
+
+	err := VerifyReplay(store)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = VerifyChain(store)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Both validations are still in line with the log.
+	for i, validation := range validations {
+		results, err := InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{seedActor("handle-0")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if results[0].Status != EventRecordStatusRejected {
+			t.Errorf("validation %d accepts a taken handle: %+v", i, results[0])
+		}
+	}
+}
+
+func TestInsertAndCheckEventsRollsBack(t *testing.T) {
+	for name, store := range map[string]Store{
+		"sqlite": newTestStore(t),
+		"memory": NewMemoryStore(),
+	} {
+		validation := NewValidation()
+
+		_, err := InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{seedActor("root")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The batch refers to a public key the store does not know.
+		_, err = InsertAndCheckEvents(store, validation, -1, 0, &Batch{PublicKey: []byte("unknown")}, []*proto.Event{seedActor("art-coffee")})
+		if err == nil {
+			t.Fatalf("%s: insert with an unknown public key succeeded", name)
+		}
+
+		records, err := store.GetEvents(-1, EventRecordStatusAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(records) != 1 {
+			t.Errorf("%s: %d events stored, want 1", name, len(records))
+		}
+	}
+}
+
+func TestPOSTStateIdempotencyKey(t *testing.T) {
+	for name, store := range map[string]Store{
+		"sqlite": openTestStore(t),
+		"memory": NewMemoryStore(),
+	} {
+		now := time.Now()
+
+		validation := NewValidation()
+		validation.IdempotencyWindow = time.Hour
+		validation.now = func() time.Time { return now }
+
+		handler := POSTState(store, validation)
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := &proto.Events{
+			Events:         []*proto.Event{seedActor("art-coffee")},
+			IdempotencyKey: "retry",
+		}
+
+		_, first := postEvents(t, handler, key, request)
+		_, retry := postEvents(t, handler, key, request)
+
+		if diff := cmp.Diff(first, retry); diff != "" {
+			t.Errorf("%s: retry results (-first +retry):\n%s", name, diff)
+		}
+
+		if stored := storedStatuses(t, store); len(stored) != 1 {
+			t.Errorf("%s: %d events stored after a retry, want 1", name, len(stored))
+		}
+
+		now = now.Add(2 * time.Hour)
+
+		_, late := postEvents(t, handler, key, request)
+
+		if len(late) != 1 || late[0].Ts == first[0].Ts {
+			t.Errorf("%s: retry after the window got %v, want a new event", name, late)
+		}
+	}
+}
+
+func TestPOSTStateRenameAndDeactivate(t *testing.T) {
+	store := openTestStore(t)
+	validation := NewValidation()
+	handler := POSTState(store, validation)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(events ...*proto.Event) (int, []RunEventResult) {
+		t.Helper()
+
+		return postEvents(t, handler, key, &proto.Events{Events: events})
+	}
+
+	root := func(event *proto.Event) {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if results[0].Status != EventRecordStatusAccepted {
+			t.Fatalf("root event: %+v", results[0])
+		}
+	}
+
+	post(seedActor("art-cofee"))
+
+	actorID, err := validation.FindActorIDByHandle(store, "art-cofee")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root(&proto.Event{Msg: &proto.Event_RenameActor{RenameActor: &proto.EventRenameActor{Handle: "art-cofee", NewHandle: "art-coffee"}}})
+
+	if id, err := validation.FindActorIDByHandle(store, "art-coffee"); err != nil || id != actorID {
+		t.Errorf("renamed handle: actor %d, %v, want %d", id, err, actorID)
+	}
+
+	if _, err := validation.FindActorIDByHandle(store, "art-cofee"); err == nil {
+		t.Error("previous handle still found")
+	}
+
+	if _, results := post(seedPlayer("art-coffee", "player:coffee-art")); results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("player of the renamed handle: %+v", results[0])
+	}
+
+	events, err := FetchEvents(store, NewProjections(), actorID, ActorSpacePlayer, "", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 3 || events[2].GetSeedPlayer() == nil {
+		t.Errorf("player projection after rename: %v", events)
+	}
+
+	activation := func(reactivate bool) *proto.Event {
+		return &proto.Event{Msg: &proto.Event_ActorActivation{ActorActivation: &proto.EventActorActivation{Handle: "art-coffee", Reactivate: reactivate}}}
+	}
+
+	root(activation(false))
+
+	if code, _ := post(playerCharacter("player:coffee-art", "character:1")); code != http.StatusBadRequest {
+		t.Errorf("deactivated actor got status %d", code)
+	}
+
+	root(activation(true))
+
+	if code, results := post(playerCharacter("player:coffee-art", "character:1")); code != http.StatusOK || results[0].Status != EventRecordStatusAccepted {
+		t.Errorf("reactivated actor got status %d, %+v", code, results)
+	}
+}
+
+func TestStutteringEventsCapabilities(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	keys := map[string]*ecdsa.PrivateKey{}
+
+	for _, role := range []string{PermissionOrga, PermissionOrgaReadOnly, PermissionScenarist, PermissionLogistics, PermissionMedical} {
+		actorID, err := store.InsertActor(ActorSpaceOrga)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys[role], err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = store.LinkState(actorID, publicKeyBytes(keys[role].PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{permission(actorID, role, false)})
+		if err != nil || results[0].Status != EventRecordStatusAccepted {
+			t.Fatalf("grant %s: %+v, %v", role, results, err)
+		}
+	}
+
+	// Events of an unknown player stay stuttering on replay.
+	tss, err := store.InsertEvents(2, nil, []*proto.Event{
+		{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:ghost")}},
+		playerCharacter("player:ghost", "character:1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ts := range tss {
+		err = store.UpdateEventStatus(ts, EventRecordStatusStuttering, "player does not exist")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	person, character := tss[0], tss[1]
+
+	list := func(role string) []stutteringEventResponse {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodGet, "/stuttering", nil)
+		r.Header.Set("Authorization", signPayload(t, keys[role], nil))
+
+		w := httptest.NewRecorder()
+
+		HandleStutteringEvents(store, validation)(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: list status %d", role, w.Code)
+		}
+
+		var response []stutteringEventResponse
+
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return response
+	}
+
+	for role, want := range map[string][]int64{
+		PermissionOrga:         {person, character},
+		PermissionOrgaReadOnly: {person, character},
+		PermissionScenarist:    {character},
+		PermissionLogistics:    {person},
+		PermissionMedical:      {person},
+	} {
+		got := list(role)
+
+		var tss []int64
+
+		for _, event := range got {
+			tss = append(tss, event.Ts)
+
+			if strings.Contains(string(event.Event), "0600000000") != (role == PermissionMedical) {
+				t.Errorf("%s: medical fields of %s", role, event.Event)
+			}
+		}
+
+		if diff := cmp.Diff(want, tss); diff != "" {
+			t.Errorf("%s: listed events (-want +got):\n%s", role, diff)
+		}
+	}
+
+	resolve := func(role string, ts int64) int {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodPost, "/stuttering/"+strconv.FormatInt(ts, 10), nil)
+		r.SetPathValue("ts", strconv.FormatInt(ts, 10))
+		r.Header.Set("Authorization", signPayload(t, keys[role], nil))
+
+		w := httptest.NewRecorder()
+
+		HandleResolveStutteringEvent(store, validation)(w, r)
+
+		return w.Code
+	}
+
+	for _, test := range []struct {
+		role string
+		ts   int64
+		code int
+	}{
+		{PermissionOrgaReadOnly, character, http.StatusBadRequest},
+		{PermissionLogistics, person, http.StatusBadRequest},
+		{PermissionScenarist, person, http.StatusBadRequest},
+		{PermissionScenarist, character, http.StatusOK},
+		{PermissionOrga, character, http.StatusNotFound},
+		{PermissionOrga, person, http.StatusOK},
+	} {
+		if code := resolve(test.role, test.ts); code != test.code {
+			t.Errorf("%s resolving %d: status %d, want %d", test.role, test.ts, code, test.code)
+		}
+	}
+}
+
+func TestGETStateSignedHead(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.LinkState(2, publicKeyBytes(key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := InsertAndCheckEvents(store, validation, -1, 2, nil, []*proto.Event{seedActor("art-coffee")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() *proto.Events {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodGet, "/state?from=-1", nil)
+		r.Header.Set("Authorization", signPayload(t, key, nil))
+
+		w := httptest.NewRecorder()
+
+		HandleState(store, validation, NewProjections())(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+		}
+
+		var response proto.Events
+
+		err := protolib.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &response
+	}
+
+	if response := get(); response.Head != nil || response.HeadSignature != nil {
+		t.Fatalf("head sent without a head key: %x", response.Head)
+	}
+
+	validation.HeadKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := VerifyChain(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := get()
+
+	if response.HeadTs != results[0].Ts || !bytes.Equal(response.Head, head) {
+		t.Fatalf("got head %d %x, want %d %x", response.HeadTs, response.Head, results[0].Ts, head)
+	}
+
+	if !VerifyHead(&validation.HeadKey.PublicKey, response.HeadTs, response.Head, response.HeadSignature) {
+		t.Fatal("head signature does not verify")
+	}
+
+	if VerifyHead(&validation.HeadKey.PublicKey, response.HeadTs+1, response.Head, response.HeadSignature) {
+		t.Fatal("head signature verifies another ts")
+	}
+}
@@ -1,66 +1,196 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-
-	_ "embed"
+	"strconv"
+	"time"
 
 	"github.com/ebenaum/thekeeper/proto"
 	"github.com/jmoiron/sqlx"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func usage() string {
-	return fmt.Sprintf("./cmd http <db-path>|https <db-path> <certfile> <keyfile>|create-orga <db-path> <handle>|link-orga <db-path> <handle>")
+	return fmt.Sprintf("./cmd http <db-path> [server-flags]|https <db-path> <certfile> <keyfile> [server-flags]|backup <db-path> <out>|restore <db-path> <backup>|verify <db-path>|seal <db-path>|authorship <db-path> <ts>|export <db-path> [out]|import <db-path> <in>|create-orga <db-path> <handle>|link-orga <db-path> <handle>|grant <db-path> <handle> <permission>|revoke <db-path> <handle> <permission>|rename <db-path> <handle> <new-handle>|deactivate <db-path> <handle>|reactivate <db-path> <handle>|erase <db-path> <player-id>|transfer <db-path> <character-id> <player-id>|reset <db-path> <edition>|univers <db-path> <univers.json>|groups <db-path> <groups.json>|migrate <db-path> [--dry-run]|demo <handle>\n\n%s names the file of the medical key. http and https refuse to start without it, seal uses it to seal the medical fields stored before it was set. The other commands reading the events, all but backup, export, import and migrate, need it once medical fields are sealed, restore included as it replays the backup.", MedicalKeyEnv)
 }
 
-//go:embed schema.sql
-var schema string
-
 func main() {
 	if len(os.Args) < 3 {
 		fmt.Println(usage())
 		os.Exit(1)
 	}
 
-	db, err := sqlx.Open("sqlite3", fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000", os.Args[2]))
+	err := CheckRegistrationRules(RegistrationRules)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer db.Close()
+	if os.Args[1] == "demo" {
+		err := demo(os.Args[2])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 
-	_, err = db.Exec(schema)
+		return
+	}
+
+	db, err := OpenDB(os.Args[2])
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	defer db.Close()
+
+	if path := os.Getenv(MedicalKeyEnv); path != "" {
+		fieldCipher, err = LoadFieldCipher(path)
+		if err != nil {
+			log.Fatal(fmt.Errorf("medical key: %w", err))
+		}
+	} else if os.Args[1] == "http" || os.Args[1] == "https" {
+		log.Fatalf("%s is not set, the medical fields cannot be sealed at rest", MedicalKeyEnv)
+	}
+
+	if os.Args[1] != "migrate" {
+		_, err = Migrate(db, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	store := NewSQLiteStore(db)
+
 	switch os.Args[1] {
 	case "http":
-		err = httpserver(db)
+		var validation *Validation
+
+		validation, err = serverFlags(db, os.Args[3:])
+		if err == nil {
+			err = httpserver(store, validation)
+		}
 	case "https":
 		if len(os.Args) < 5 {
 			fmt.Println(usage())
 			os.Exit(1)
 		}
-		err = httpsserver(db)
+
+		var validation *Validation
+
+		validation, err = serverFlags(db, os.Args[5:])
+		if err == nil {
+			err = httpsserver(store, validation)
+		}
+	case "backup":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = Backup(db, os.Args[3])
+	case "restore":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = Restore(db, os.Args[3])
+	case "verify":
+		err = verify(store)
+	case "seal":
+		err = seal(store)
+	case "authorship":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = authorship(store, os.Args[3])
+	case "export":
+		err = export(db, os.Args[3:])
+	case "import":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = importfile(db, os.Args[3])
 	case "reset":
-		err = insertreset(db)
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = insertreset(store, os.Args[3])
+	case "univers":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = insertunivers(store, os.Args[3])
 	case "create-orga":
 		if len(os.Args) < 4 {
 			fmt.Println(usage())
 			os.Exit(1)
 		}
 
-		err = createorga(db, os.Args[3])
+		err = createorga(store, os.Args[3])
 	case "link-orga":
 		if len(os.Args) < 4 {
 			fmt.Println(usage())
 			os.Exit(1)
 		}
-		err = linkorga(db, os.Args[3])
+		err = linkorga(store, os.Args[3])
+	case "grant", "revoke":
+		if len(os.Args) < 5 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = insertpermission(store, os.Args[3], os.Args[4], os.Args[1] == "revoke")
+	case "rename":
+		if len(os.Args) < 5 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = renameactor(store, os.Args[3], os.Args[4])
+	case "deactivate", "reactivate":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = insertactivation(store, os.Args[3], os.Args[1] == "reactivate")
+	case "erase":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = eraseplayer(store, os.Args[3])
+	case "transfer":
+		if len(os.Args) < 5 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = transfercharacter(store, os.Args[3], os.Args[4])
+	case "groups":
+		if len(os.Args) < 4 {
+			fmt.Println(usage())
+			os.Exit(1)
+		}
+
+		err = insertgroups(store, os.Args[3])
+	case "migrate":
+		err = migrate(db, len(os.Args) > 3 && os.Args[3] == "--dry-run")
 	default:
 		fmt.Println(usage())
 		os.Exit(1)
@@ -72,34 +202,95 @@ func main() {
 	}
 }
 
-func httpserver(db *sqlx.DB) error {
-	http.HandleFunc("/state", HandleState(db))
-	http.HandleFunc("/auth/handles/{handle}", HandleCreateAuthKey(db))
-	http.HandleFunc("/auth/redeem/{key}", HandleRedeemAuthKey(db))
+// serverFlags parses the flags of the http and https commands. It starts the
+// periodic backups of db when args hold a -backup-dir flag and returns the
+// validation of the server.
+func serverFlags(db *sqlx.DB, args []string) (*Validation, error) {
+	var schedule BackupSchedule
+	var headKey string
+
+	validation := NewValidation()
+
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	flags.StringVar(&schedule.Dir, "backup-dir", "", "directory of the periodic backups, disabled when empty")
+	flags.DurationVar(&schedule.Interval, "backup-interval", time.Hour, "time between two backups")
+	flags.IntVar(&schedule.Keep, "backup-keep", 48, "number of backups to keep, all when 0")
+	flags.BoolVar(&validation.DiscardRejected, "discard-rejected", false, "do not store the submitted events that are rejected")
+	flags.DurationVar(&validation.IdempotencyWindow, "idempotency-window", validation.IdempotencyWindow, "time the idempotency keys of the submitted batches are kept")
+	flags.StringVar(&headKey, "head-key", "", "PEM PKCS #8 P-256 private key signing the head of the log sent with the events, unsigned when empty")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if headKey != "" {
+		validation.HeadKey, err = LoadHeadKey(headKey)
+		if err != nil {
+			return nil, fmt.Errorf("head key: %w", err)
+		}
+
+		jwk, err := HeadKeyJWK(validation.HeadKey)
+		if err != nil {
+			return nil, fmt.Errorf("head key: %w", err)
+		}
+
+		log.Printf("head key %s", jwk)
+	}
+
+	if schedule.Dir == "" {
+		return validation, nil
+	}
+
+	if schedule.Interval <= 0 {
+		return nil, fmt.Errorf("invalid backup interval %v", schedule.Interval)
+	}
+
+	err = os.MkdirAll(schedule.Dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("backup dir: %w", err)
+	}
+
+	go schedule.Run(db)
+
+	return validation, nil
+}
+
+func httpserver(store Store, validation *Validation) error {
+	projections := NewProjections()
+
+	http.HandleFunc("/state", HandleState(store, validation, projections))
+	http.HandleFunc("/auth/handles/{handle}", HandleCreateAuthKey(store, validation))
+	http.HandleFunc("/auth/redeem/{key}", HandleRedeemAuthKey(store, validation))
+	http.HandleFunc("/stuttering", HandleStutteringEvents(store, validation))
+	http.HandleFunc("/stuttering/{ts}", HandleResolveStutteringEvent(store, validation))
+	http.HandleFunc("/groups", HandleGroups(store, validation))
 
 	return http.ListenAndServe(":8081", nil)
 }
 
-func httpsserver(db *sqlx.DB) error {
-	http.HandleFunc("/state", HandleState(db))
-	http.HandleFunc("/auth/handles/{handle}", HandleCreateAuthKey(db))
-	http.HandleFunc("/auth/redeem/{key}", HandleRedeemAuthKey(db))
+func httpsserver(store Store, validation *Validation) error {
+	projections := NewProjections()
+
+	http.HandleFunc("/state", HandleState(store, validation, projections))
+	http.HandleFunc("/auth/handles/{handle}", HandleCreateAuthKey(store, validation))
+	http.HandleFunc("/auth/redeem/{key}", HandleRedeemAuthKey(store, validation))
+	http.HandleFunc("/stuttering", HandleStutteringEvents(store, validation))
+	http.HandleFunc("/stuttering/{ts}", HandleResolveStutteringEvent(store, validation))
+	http.HandleFunc("/groups", HandleGroups(store, validation))
 
 	return http.ListenAndServeTLS(":443", os.Args[3], os.Args[4], nil)
 }
 
-func createorga(db *sqlx.DB, orgaHandle string) error {
-	var id int64
-
-	err := db.QueryRowx(
-		`INSERT INTO actors (space) VALUES (?) RETURNING id`,
-		ActorSpaceOrga,
-	).Scan(&id)
+func createorga(store Store, orgaHandle string) error {
+	id, err := store.InsertActor(ActorSpaceOrga)
 	if err != nil {
 		return fmt.Errorf("insert actor: %w", err)
 	}
 
-	result, err := InsertAndCheckEvents(db, -1, id, []*proto.Event{
+	validation := NewValidation()
+
+	result, err := InsertAndCheckEvents(store, validation, -1, id, nil, []*proto.Event{
 		{
 			Msg: &proto.Event_SeedActor{
 				SeedActor: &proto.EventSeedActor{
@@ -116,7 +307,7 @@ func createorga(db *sqlx.DB, orgaHandle string) error {
 		return fmt.Errorf("seeding actor event was not accepted: %v", result[0])
 	}
 
-	result, err = InsertAndCheckEvents(db, -1, 0, []*proto.Event{
+	result, err = InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{
 		{
 			Msg: &proto.Event_Permission{
 				Permission: &proto.EventPermission{
@@ -134,7 +325,7 @@ func createorga(db *sqlx.DB, orgaHandle string) error {
 		return fmt.Errorf("inserting permission event was not accepted: %v", result[0])
 	}
 
-	code, err := InsertAuthKey(db, id)
+	code, err := store.InsertAuthKey(id)
 	if err != nil {
 		return fmt.Errorf("inserting link code %w", err)
 	}
@@ -144,32 +335,238 @@ func createorga(db *sqlx.DB, orgaHandle string) error {
 	return nil
 }
 
-func insertreset(db *sqlx.DB) error {
+func insertreset(store Store, edition string) error {
 	var id int64
 
-	result, err := InsertAndCheckEvents(db, -1, id, []*proto.Event{
+	result, err := InsertAndCheckEvents(store, NewValidation(), -1, id, nil, []*proto.Event{
 		{
-			Msg: &proto.Event_Reset_{},
+			Msg: &proto.Event_Edition{
+				Edition: &proto.EventEdition{
+					Name: edition,
+				},
+			},
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("seeding actor event: %w", err)
+		return fmt.Errorf("insert edition event: %w", err)
 	}
 
 	if result[0].Status != EventRecordStatusAccepted {
-		return fmt.Errorf("seeding actor event was not accepted: %v", result[0])
+		return fmt.Errorf("edition event was not accepted: %v", result[0])
+	}
+
+	return nil
+}
+
+// insertunivers enforces the character creation rules of the univers JSON at
+// path on the characters saved from now on.
+func insertunivers(store Store, path string) error {
+	definition, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read univers: %w", err)
+	}
+
+	_, err = ParseUnivers(definition)
+	if err != nil {
+		return fmt.Errorf("parse univers: %w", err)
+	}
+
+	result, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{
+		{
+			Msg: &proto.Event_Univers{
+				Univers: &proto.EventUnivers{
+					Definition: definition,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert univers event: %w", err)
+	}
+
+	if result[0].Status != EventRecordStatusAccepted {
+		return fmt.Errorf("univers event was not accepted: %v", result[0])
+	}
+
+	return nil
+}
+
+// insertgroups defines the groups of a JSON array of EventGroup, a group
+// already defined is redefined.
+func insertgroups(store Store, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read groups: %w", err)
+	}
+
+	var definitions []json.RawMessage
+
+	err = json.Unmarshal(data, &definitions)
+	if err != nil {
+		return fmt.Errorf("parse groups: %w", err)
+	}
+
+	events := make([]*proto.Event, len(definitions))
+
+	for i, definition := range definitions {
+		var group proto.EventGroup
+
+		err = protojson.Unmarshal(definition, &group)
+		if err != nil {
+			return fmt.Errorf("parse group #%d: %w", i, err)
+		}
+
+		events[i] = &proto.Event{Msg: &proto.Event_Group{Group: &group}}
+	}
+
+	results, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, events)
+	if err != nil {
+		return fmt.Errorf("insert group events: %w", err)
+	}
+
+	for i, result := range results {
+		if result.Status != EventRecordStatusAccepted {
+			return fmt.Errorf("group %q was not accepted: %v", events[i].GetGroup().GroupId, result)
+		}
 	}
 
 	return nil
 }
 
-func linkorga(db *sqlx.DB, orgaHandle string) error {
-	actorIDToLink, err := FindActorIDByHandle(db, orgaHandle)
+// insertpermission grants the permission to the actor of handle, or revokes
+// it.
+func insertpermission(store Store, handle string, permission string, revoke bool) error {
+	validation := NewValidation()
+
+	actorID, err := validation.FindActorIDByHandle(store, handle)
 	if err != nil {
 		return fmt.Errorf("find actor by handle: %w", err)
 	}
 
-	authKey, err := InsertAuthKey(db, actorIDToLink)
+	result, err := InsertAndCheckEvents(store, validation, -1, 0, nil, []*proto.Event{
+		{
+			Msg: &proto.Event_Permission{
+				Permission: &proto.EventPermission{
+					ActorId:    actorID,
+					Permission: permission,
+					Revoke:     revoke,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert permission event: %w", err)
+	}
+
+	if result[0].Status != EventRecordStatusAccepted {
+		return fmt.Errorf("permission event was not accepted: %v", result[0])
+	}
+
+	return nil
+}
+
+func renameactor(store Store, handle string, newHandle string) error {
+	result, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{
+		{
+			Msg: &proto.Event_RenameActor{
+				RenameActor: &proto.EventRenameActor{
+					Handle:    handle,
+					NewHandle: newHandle,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert rename event: %w", err)
+	}
+
+	if result[0].Status != EventRecordStatusAccepted {
+		return fmt.Errorf("rename event was not accepted: %v", result[0])
+	}
+
+	return nil
+}
+
+// insertactivation deactivates the actor of handle, or reactivates it.
+func insertactivation(store Store, handle string, reactivate bool) error {
+	result, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{
+		{
+			Msg: &proto.Event_ActorActivation{
+				ActorActivation: &proto.EventActorActivation{
+					Handle:     handle,
+					Reactivate: reactivate,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert activation event: %w", err)
+	}
+
+	if result[0].Status != EventRecordStatusAccepted {
+		return fmt.Errorf("activation event was not accepted: %v", result[0])
+	}
+
+	return nil
+}
+
+// eraseplayer erases the personal data of a player. The backups taken
+// before keep it.
+func eraseplayer(store Store, playerID string) error {
+	result, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{
+		{
+			Msg: &proto.Event_PlayerErasure{
+				PlayerErasure: &proto.EventPlayerErasure{
+					PlayerId: playerID,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert erasure event: %w", err)
+	}
+
+	if result[0].Status != EventRecordStatusAccepted {
+		return fmt.Errorf("erasure event was not accepted: %v", result[0])
+	}
+
+	fmt.Println("Erasure:", result[0].Ts)
+
+	return nil
+}
+
+// transfercharacter hands a character over to another player.
+func transfercharacter(store Store, characterID, playerID string) error {
+	result, err := InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{
+		{
+			Msg: &proto.Event_CharacterTransfer{
+				CharacterTransfer: &proto.EventCharacterTransfer{
+					CharacterId: characterID,
+					PlayerId:    playerID,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert transfer event: %w", err)
+	}
+
+	if result[0].Status != EventRecordStatusAccepted {
+		return fmt.Errorf("transfer event was not accepted: %v", result[0])
+	}
+
+	fmt.Println("Transfer:", result[0].Ts)
+
+	return nil
+}
+
+func linkorga(store Store, orgaHandle string) error {
+	actorIDToLink, err := NewValidation().FindActorIDByHandle(store, orgaHandle)
+	if err != nil {
+		return fmt.Errorf("find actor by handle: %w", err)
+	}
+
+	authKey, err := store.InsertAuthKey(actorIDToLink)
 	if err != nil {
 		return fmt.Errorf("inserting link code %w", err)
 	}
@@ -178,3 +575,116 @@ func linkorga(db *sqlx.DB, orgaHandle string) error {
 
 	return nil
 }
+
+func migrate(db *sqlx.DB, dryRun bool) error {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("schema version: %w", err)
+	}
+
+	fmt.Println("Schema version:", version)
+
+	migrations, err := Migrate(db, dryRun)
+	for _, migration := range migrations {
+		if dryRun {
+			fmt.Printf("pending %04d %s\n", migration.Version, migration.Name)
+		} else {
+			fmt.Printf("applied %04d %s\n", migration.Version, migration.Name)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	return nil
+}
+
+// demo serves an ephemeral in-memory state with a single orga.
+func demo(orgaHandle string) error {
+	store := NewMemoryStore()
+
+	err := createorga(store, orgaHandle)
+	if err != nil {
+		return fmt.Errorf("create orga: %w", err)
+	}
+
+	return httpserver(store, NewValidation())
+}
+
+func export(db *sqlx.DB, args []string) error {
+	if len(args) == 0 {
+		return Export(db, os.Stdout)
+	}
+
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+
+	err = Export(db, w)
+	if err != nil {
+		f.Close()
+
+		return fmt.Errorf("export: %w", err)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		f.Close()
+
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return f.Close()
+}
+
+func importfile(db *sqlx.DB, in string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	defer f.Close()
+
+	return Import(db, f)
+}
+
+func verify(store Store) error {
+	head, err := VerifyChain(store)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	fmt.Printf("Head: %x\n", head)
+
+	return nil
+}
+
+func seal(store *SQLiteStore) error {
+	events, batches, err := store.SealMedical()
+	if err != nil {
+		return fmt.Errorf("seal: %w", err)
+	}
+
+	fmt.Printf("Sealed %d events and %d batches\n", events, batches)
+
+	return nil
+}
+
+func authorship(store Store, tsString string) error {
+	ts, err := strconv.ParseInt(tsString, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse ts: %w", err)
+	}
+
+	authorship, err := VerifyAuthorship(store, ts)
+	if err != nil {
+		return fmt.Errorf("verify authorship: %w", err)
+	}
+
+	fmt.Printf("Event %d authored by actor %d with public key %x\n", authorship.Ts, authorship.SourceActorID, authorship.PublicKey)
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ebenaum/thekeeper/proto"
+	protolib "google.golang.org/protobuf/proto"
+)
+
+// MedicalKeyEnv names the environment variable holding the path of the file
+// of the medical key, 32 hex encoded bytes.
+const MedicalKeyEnv = "THEKEEPER_MEDICAL_KEY_FILE"
+
+// sealedPrefix marks the values sealed by a FieldCipher.
+const sealedPrefix = "sealed:"
+
+// ErrMedicalKey is returned when sealed data is read or sealed without the
+// medical key.
+var ErrMedicalKey = errors.New("sealed medical data: the medical key is missing, set " + MedicalKeyEnv)
+
+// FieldCipher seals the medical data of the events at rest with AES-GCM.
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+// fieldCipher seals the medical data stored by SQLiteStore, nil leaves it in
+// plaintext.
+var fieldCipher *FieldCipher
+
+func NewFieldCipher(key []byte) (*FieldCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("gcm: %w", err)
+	}
+
+	return &FieldCipher{aead: aead}, nil
+}
+
+// LoadFieldCipher reads the key file at path.
+func LoadFieldCipher(path string) (*FieldCipher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key of %d bytes, want 32", len(key))
+	}
+
+	return NewFieldCipher(key)
+}
+
+func (c *FieldCipher) seal(plaintext []byte) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	rand.Read(nonce)
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func (c *FieldCipher) open(sealed []byte) ([]byte, error) {
+	if c == nil {
+		return nil, ErrMedicalKey
+	}
+
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+
+	return c.aead.Open(nil, sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():], nil)
+}
+
+func (c *FieldCipher) sealString(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return sealedPrefix + base64.StdEncoding.EncodeToString(c.seal([]byte(value)))
+}
+
+func (c *FieldCipher) openString(value string) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(value[len(sealedPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("decode sealed value: %w", err)
+	}
+
+	plaintext, err := c.open(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// SealPayload seals a batch payload, a nil cipher returns it as is.
+func (c *FieldCipher) SealPayload(payload []byte) []byte {
+	if c == nil || len(payload) == 0 {
+		return payload
+	}
+
+	return append([]byte(sealedPrefix), c.seal(payload)...)
+}
+
+// OpenPayload opens a payload sealed by SealPayload. Payloads stored before
+// the key was set are returned as is.
+func (c *FieldCipher) OpenPayload(payload []byte) ([]byte, error) {
+	if !bytes.HasPrefix(payload, []byte(sealedPrefix)) {
+		return payload, nil
+	}
+
+	return c.open(payload[len(sealedPrefix):])
+}
+
+// SealEvent returns event with its medical fields sealed, a nil cipher
+// returns event.
+func (c *FieldCipher) SealEvent(event *proto.Event) *proto.Event {
+	person := event.GetPlayerPerson()
+	if c == nil || person == nil {
+		return event
+	}
+
+	sealed := protolib.Clone(event).(*proto.Event)
+	sealed.GetPlayerPerson().Health = c.sealString(person.Health)
+	sealed.GetPlayerPerson().EmergencyContact = c.sealString(person.EmergencyContact)
+
+	return sealed
+}
+
+// plaintextMedical tells whether event holds medical fields stored before
+// the medical key was set.
+func plaintextMedical(event *proto.Event) bool {
+	person := event.GetPlayerPerson()
+	if person == nil {
+		return false
+	}
+
+	for _, value := range []string{person.Health, person.EmergencyContact} {
+		if value != "" && !strings.HasPrefix(value, sealedPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenEvent opens the medical fields of event in place.
+func (c *FieldCipher) OpenEvent(event *proto.Event) error {
+	person := event.GetPlayerPerson()
+	if person == nil {
+		return nil
+	}
+
+	var err error
+
+	person.Health, err = c.openString(person.Health)
+	if err != nil {
+		return fmt.Errorf("health: %w", err)
+	}
+
+	person.EmergencyContact, err = c.openString(person.EmergencyContact)
+	if err != nil {
+		return fmt.Errorf("emergency contact: %w", err)
+	}
+
+	return nil
+}
+
+// MedicalFields are the fields of an EventPlayerPerson only the actors able
+// to CapabilityReadMedical receive.
+type MedicalFields struct {
+	Health           string
+	EmergencyContact string
+}
+
+// withMedical returns person completed with the medical fields of the
+// previous registration of the player when it is an edit based on a redacted
+// registration.
+func withMedical(person *proto.EventPlayerPerson, previous map[string]MedicalFields) *proto.EventPlayerPerson {
+	if !person.MedicalRedacted {
+		return person
+	}
+
+	medical := previous[person.PlayerId]
+
+	merged := protolib.Clone(person).(*proto.EventPlayerPerson)
+	merged.Health = medical.Health
+	merged.EmergencyContact = medical.EmergencyContact
+	merged.MedicalRedacted = false
+
+	return merged
+}
+
+// projectPerson returns a registration event as projections keep it: an edit
+// based on a redacted registration gets the medical fields of the previous
+// registration, which medical follows.
+func projectPerson(event *proto.Event, medical map[string]MedicalFields) *proto.Event {
+	person := withMedical(event.GetPlayerPerson(), medical)
+
+	medical[person.PlayerId] = MedicalFields{person.Health, person.EmergencyContact}
+
+	if person == event.GetPlayerPerson() {
+		return event
+	}
+
+	return &proto.Event{Ts: event.Ts, Msg: &proto.Event_PlayerPerson{PlayerPerson: person}}
+}
+
+// redactMedical returns a copy of event without the medical fields.
+func redactMedical(event *proto.Event) *proto.Event {
+	redacted := protolib.Clone(event).(*proto.Event)
+	redacted.GetPlayerPerson().Health = ""
+	redacted.GetPlayerPerson().EmergencyContact = ""
+	redacted.GetPlayerPerson().MedicalRedacted = true
+
+	return redacted
+}
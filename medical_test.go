@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+func TestMedicalFieldsSealedAtRest(t *testing.T) {
+	cipher, err := NewFieldCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldCipher = cipher
+	t.Cleanup(func() { fieldCipher = nil })
+
+	store := openTestStore(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	person := playerPerson("player:coffee-art")
+	person.Health = "allergic to peanuts"
+
+	results := submitBatch(t, store, key,
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		&proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}},
+	)
+
+	if results[2].Status != EventRecordStatusAccepted {
+		t.Fatalf("registration: %+v", results[2])
+	}
+
+	var raw [][]byte
+
+	err = store.db.Select(&raw, `SELECT data FROM events UNION ALL SELECT payload FROM batches`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range raw {
+		for _, plaintext := range []string{"peanuts", "0600000000"} {
+			if bytes.Contains(data, []byte(plaintext)) {
+				t.Errorf("%q stored in plaintext", plaintext)
+			}
+		}
+	}
+
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := records[2].Event.GetPlayerPerson(); got.Health != person.Health || got.EmergencyContact != person.EmergencyContact {
+		t.Errorf("opened registration: %v", got)
+	}
+
+	_, err = VerifyAuthorship(store, results[2].Ts)
+	if err != nil {
+		t.Errorf("authorship: %v", err)
+	}
+
+	err = VerifyReplay(store)
+	if err != nil {
+		t.Errorf("replay: %v", err)
+	}
+
+	fieldCipher = nil
+
+	_, err = store.GetEvents(-1, EventRecordStatusAll)
+	if !errors.Is(err, ErrMedicalKey) {
+		t.Errorf("reading without the key: %v", err)
+	}
+}
+
+func TestSealMedical(t *testing.T) {
+	store := openTestStore(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	person := playerPerson("player:coffee-art")
+	person.Health = "allergic to peanuts"
+
+	// Registered before the medical key was set.
+	results := submitBatch(t, store, key,
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		&proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}},
+	)
+
+	_, _, err = store.SealMedical()
+	if !errors.Is(err, ErrMedicalKey) {
+		t.Errorf("sealing without the key: %v", err)
+	}
+
+	cipher, err := NewFieldCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldCipher = cipher
+	t.Cleanup(func() { fieldCipher = nil })
+
+	for i, want := range [][2]int{{1, 1}, {0, 0}} {
+		events, batches, err := store.SealMedical()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if events != want[0] || batches != want[1] {
+			t.Errorf("#%d: sealed %d events and %d batches, want %v", i, events, batches, want)
+		}
+	}
+
+	var raw [][]byte
+
+	err = store.db.Select(&raw, `SELECT data FROM events UNION ALL SELECT payload FROM batches`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range raw {
+		if bytes.Contains(data, []byte("peanuts")) {
+			t.Error("medical fields still stored in plaintext")
+		}
+	}
+
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := records[2].Event.GetPlayerPerson(); got.Health != person.Health {
+		t.Errorf("opened registration: %v", got)
+	}
+
+	_, err = VerifyChain(store)
+	if err != nil {
+		t.Errorf("chain: %v", err)
+	}
+
+	_, err = VerifyAuthorship(store, results[2].Ts)
+	if err != nil {
+		t.Errorf("authorship: %v", err)
+	}
+}
+
+func TestMedicalFieldsRedacted(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	person := playerPerson("player:coffee-art")
+	person.Health = "allergic to peanuts"
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	registered := insert(2, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}})
+
+	medicalID, err := store.InsertActor(ActorSpaceOrga)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insert(0, permission(1, PermissionOrga, false))
+	insert(0, permission(medicalID, PermissionMedical, false))
+
+	latest := func(actorID int64) *proto.EventPlayerPerson {
+		t.Helper()
+
+		events, err := FetchEvents(store, NewProjections(), actorID, ActorSpaceOrga, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var latest *proto.EventPlayerPerson
+
+		for _, event := range events {
+			if event.GetPlayerPerson() != nil {
+				latest = event.GetPlayerPerson()
+			}
+		}
+
+		return latest
+	}
+
+	redacted := latest(1)
+	if redacted.Health != "" || redacted.EmergencyContact != "" || !redacted.MedicalRedacted {
+		t.Errorf("orga gets %v", redacted)
+	}
+
+	if got := latest(medicalID); got.Health != person.Health || got.MedicalRedacted {
+		t.Errorf("medical actor gets %v", got)
+	}
+
+	// The orga edits the registration it received, the medical fields stay.
+	redacted.Surname = "Jeanne"
+	redacted.ExpectedVersion = registered.Ts
+
+	edited := insert(1, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: redacted}})
+	if edited.Status != EventRecordStatusAccepted {
+		t.Fatalf("redacted edit: %+v", edited)
+	}
+
+	if got := latest(medicalID); got.Surname != "Jeanne" || got.Health != person.Health || got.EmergencyContact != person.EmergencyContact {
+		t.Errorf("medical actor gets %v after the redacted edit", got)
+	}
+
+	overwrite := playerPerson("player:coffee-art")
+	overwrite.MedicalRedacted = true
+	overwrite.ExpectedVersion = edited.Ts
+
+	if result := insert(1, &proto.Event{Msg: &proto.Event_PlayerPerson{PlayerPerson: overwrite}}); result.Status != EventRecordStatusRejected {
+		t.Errorf("redacted edit setting medical fields: %+v", result)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns the embedded migrations ordered by version. A migration
+// file is named <version>_<name>.sql.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("glob: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+
+		rawVersion, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %q: missing name", file)
+		}
+
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil {
+			return nil, fmt.Errorf("migration %q: invalid version: %w", file, err)
+		}
+
+		data, err := migrationsFS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", file, err)
+		}
+
+		migrations = append(migrations, Migration{version, name, string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// SchemaVersion returns the version of the last migration applied to db, 0
+// when none was. It only reads the database.
+func SchemaVersion(db *sqlx.DB) (int, error) {
+	var tables int
+
+	err := db.QueryRowx(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='schema_version'`).Scan(&tables)
+	if err != nil {
+		return -1, fmt.Errorf("query sqlite_master: %w", err)
+	}
+
+	if tables == 0 {
+		return 0, nil
+	}
+
+	var version int
+
+	err = db.QueryRowx(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return -1, fmt.Errorf("query: %w", err)
+	}
+
+	return version, nil
+}
+
+// Migrate applies the migrations newer than the database schema version, each
+// in its own transaction, and returns them. With dryRun nothing is applied and
+// the pending migrations are only returned.
+func Migrate(db *sqlx.DB, dryRun bool) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, fmt.Errorf("migrations: %w", err)
+	}
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, fmt.Errorf("schema version: %w", err)
+	}
+
+	var pending []Migration
+
+	for _, migration := range migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+
+	if dryRun {
+		return pending, nil
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+	  version INTEGER PRIMARY KEY,
+	  name TEXT NOT NULL,
+	  applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create schema_version: %w", err)
+	}
+
+	for i, migration := range pending {
+		err = applyMigration(db, migration)
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+func applyMigration(db *sqlx.DB, migration Migration) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(migration.SQL)
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		migration.Version,
+		migration.Name,
+		time.Now().UTC().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert schema_version: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
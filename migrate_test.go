@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateUpgradesSchemaSQLDatabase(t *testing.T) {
+	db, err := OpenDB(filepath.Join(t.TempDir(), "thekeeper.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	schema, err := os.ReadFile("testdata/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(string(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`INSERT INTO actors (space) VALUES ('orga')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`INSERT INTO events (ts, source_actor_id, data, status) VALUES (1, 1, X'', 2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest := migrations[len(migrations)-1].Version
+
+	pending, err := Migrate(db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != len(migrations) {
+		t.Fatalf("dry run: got %d pending migrations, want %d", len(pending), len(migrations))
+	}
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != 0 {
+		t.Fatalf("dry run changed schema version to %d", version)
+	}
+
+	var tables int
+
+	err = db.QueryRowx(`SELECT COUNT(*) FROM sqlite_master WHERE name='schema_version'`).Scan(&tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tables != 0 {
+		t.Fatal("dry run created the schema_version table")
+	}
+
+	applied, err := Migrate(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != len(migrations) {
+		t.Fatalf("got %d applied migrations, want %d", len(applied), len(migrations))
+	}
+
+	version, err = SchemaVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != latest {
+		t.Fatalf("schema version %d, want %d", version, latest)
+	}
+
+	var count int
+
+	err = db.QueryRowx(`SELECT COUNT(*) FROM events`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 1 {
+		t.Fatalf("got %d events after migration, want 1", count)
+	}
+
+	applied, err = Migrate(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 0 {
+		t.Fatalf("second run applied %d migrations", len(applied))
+	}
+}
+
+func TestMigrateEventsResolvedKeepsReferences(t *testing.T) {
+	db, err := OpenDB(filepath.Join(t.TempDir(), "thekeeper.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at INTEGER NOT NULL)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version >= 7 {
+			break
+		}
+
+		err = applyMigration(db, migration)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, query := range []string{
+		`INSERT INTO actors (space) VALUES ('orga')`,
+		`INSERT INTO events (ts, source_actor_id, data, status, hash) VALUES (1, 1, X'01', 8, X'02')`,
+		`INSERT INTO events_stuttering (ts, reason) VALUES (1, 'player does not exist')`,
+		`INSERT INTO events_rewrites (ts, data_hash, head, hash) VALUES (1, X'03', X'02', X'04')`,
+	} {
+		_, err = db.Exec(query)
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	_, err = Migrate(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var violations int
+
+	err = db.QueryRowx(`SELECT COUNT(*) FROM pragma_foreign_key_check`).Scan(&violations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if violations != 0 {
+		t.Errorf("%d foreign key violations after the migration", violations)
+	}
+
+	_, err = db.Exec(`UPDATE events SET status = ? WHERE ts = 1`, EventRecordStatusResolved)
+	if err != nil {
+		t.Errorf("resolved status refused: %v", err)
+	}
+
+	// The references to events follow the new table.
+	_, err = db.Exec(`INSERT INTO events_stuttering (ts, reason) VALUES (2, 'unknown')`)
+	if err == nil {
+		t.Error("stuttering row of a missing event accepted")
+	}
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS events_stuttering (
+  ts INTEGER PRIMARY KEY,
+  reason TEXT NOT NULL,
+
+  FOREIGN KEY(ts) REFERENCES events(ts)
+);
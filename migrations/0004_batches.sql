@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS batches (
+  id INTEGER PRIMARY KEY,
+  public_key_id INTEGER NOT NULL,
+  token TEXT NOT NULL, -- ES256 JWT signed by the public key, its payload_hash claim covers payload
+  payload BLOB NOT NULL,
+
+  FOREIGN KEY(public_key_id) REFERENCES public_keys(id)
+);
+
+ALTER TABLE events ADD COLUMN batch_id INTEGER REFERENCES batches(id);
+ALTER TABLE events ADD COLUMN batch_index INTEGER;
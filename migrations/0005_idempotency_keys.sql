@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  actor_id INTEGER NOT NULL,
+  key TEXT NOT NULL,
+  created_at INTEGER NOT NULL,
+  results TEXT NOT NULL, -- JSON of the RunEventResult list of the first submission
+
+  PRIMARY KEY(actor_id, key),
+  FOREIGN KEY(actor_id) REFERENCES actors(id)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1,13 @@
+-- SHA-256 of each event of the payload as signed, concatenated, set once an
+-- erasure redacts the payload.
+ALTER TABLE batches ADD COLUMN digests BLOB;
+
+CREATE TABLE IF NOT EXISTS events_rewrites (
+  id INTEGER PRIMARY KEY,
+  ts INTEGER NOT NULL,
+  data_hash BLOB NOT NULL, -- SHA-256 of the data the event was rewritten with
+  head BLOB NOT NULL, -- hash of the last event when the rewrite was appended
+  hash BLOB NOT NULL,
+
+  FOREIGN KEY(ts) REFERENCES events(ts)
+);
@@ -0,0 +1,26 @@
+-- 16 marks the stuttering events an orga resolved, the replays leave them
+-- rejected. SQLite cannot alter a CHECK constraint: events is copied aside,
+-- created again with the new one and filled back. The checks of the tables
+-- referencing events are deferred until its rows are back.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TEMP TABLE events_copy AS SELECT ts, source_actor_id, data, status, hash, batch_id, batch_index FROM events;
+
+DROP TABLE events;
+
+CREATE TABLE events (
+  ts INTEGER PRIMARY KEY,
+  source_actor_id INTEGER NOT NULL,
+  data BLOB,
+  status INTEGER CHECK( status IN (1, 2, 4, 8, 16) ) NOT NULL, -- 1 pending, 2 accepted, 4 rejected, 8 stuttering, 16 resolved
+  hash BLOB,
+  batch_id INTEGER REFERENCES batches(id),
+  batch_index INTEGER,
+
+  FOREIGN KEY(source_actor_id) REFERENCES actors(id)
+) WITHOUT ROWID;
+
+INSERT INTO events (ts, source_actor_id, data, status, hash, batch_id, batch_index)
+SELECT ts, source_actor_id, data, status, hash, batch_id, batch_index FROM events_copy;
+
+DROP TABLE events_copy;
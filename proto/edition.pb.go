@@ -0,0 +1,127 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.4
+// 	protoc        v6.30.2
+// source: edition.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EventEdition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventEdition) Reset() {
+	*x = EventEdition{}
+	mi := &file_edition_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventEdition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventEdition) ProtoMessage() {}
+
+func (x *EventEdition) ProtoReflect() protoreflect.Message {
+	mi := &file_edition_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventEdition.ProtoReflect.Descriptor instead.
+func (*EventEdition) Descriptor() ([]byte, []int) {
+	return file_edition_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventEdition) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+var File_edition_proto protoreflect.FileDescriptor
+
+var file_edition_proto_rawDesc = string([]byte{
+	0x0a, 0x0d, 0x65, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x09, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x22, 0x22, 0x0a, 0x0c, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x45, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x2a,
+	0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x62, 0x65,
+	0x6e, 0x61, 0x75, 0x6d, 0x2f, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+})
+
+var (
+	file_edition_proto_rawDescOnce sync.Once
+	file_edition_proto_rawDescData []byte
+)
+
+func file_edition_proto_rawDescGZIP() []byte {
+	file_edition_proto_rawDescOnce.Do(func() {
+		file_edition_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_edition_proto_rawDesc), len(file_edition_proto_rawDesc)))
+	})
+	return file_edition_proto_rawDescData
+}
+
+var file_edition_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_edition_proto_goTypes = []any{
+	(*EventEdition)(nil), // 0: thekeeper.EventEdition
+}
+var file_edition_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_edition_proto_init() }
+func file_edition_proto_init() {
+	if File_edition_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_edition_proto_rawDesc), len(file_edition_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_edition_proto_goTypes,
+		DependencyIndexes: file_edition_proto_depIdxs,
+		MessageInfos:      file_edition_proto_msgTypes,
+	}.Build()
+	File_edition_proto = out.File
+	file_edition_proto_goTypes = nil
+	file_edition_proto_depIdxs = nil
+}
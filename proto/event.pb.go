@@ -33,6 +33,16 @@ type Event struct {
 	//	*Event_PlayerCharacter
 	//	*Event_Reset_
 	//	*Event_PlayerCharacterOrgaEdit
+	//	*Event_Edition
+	//	*Event_Univers
+	//	*Event_RenameActor
+	//	*Event_ActorActivation
+	//	*Event_PlayerErasure
+	//	*Event_Erased
+	//	*Event_CharacterReview
+	//	*Event_CharacterTransfer
+	//	*Event_Group
+	//	*Event_Relationship
 	Msg           isEvent_Msg `protobuf_oneof:"msg"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -145,6 +155,96 @@ func (x *Event) GetPlayerCharacterOrgaEdit() *EventPlayerCharacterOrgaEdit {
 	return nil
 }
 
+func (x *Event) GetEdition() *EventEdition {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_Edition); ok {
+			return x.Edition
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetUnivers() *EventUnivers {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_Univers); ok {
+			return x.Univers
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetRenameActor() *EventRenameActor {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_RenameActor); ok {
+			return x.RenameActor
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetActorActivation() *EventActorActivation {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_ActorActivation); ok {
+			return x.ActorActivation
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetPlayerErasure() *EventPlayerErasure {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_PlayerErasure); ok {
+			return x.PlayerErasure
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetErased() *EventErased {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_Erased); ok {
+			return x.Erased
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetCharacterReview() *EventCharacterReview {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_CharacterReview); ok {
+			return x.CharacterReview
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetCharacterTransfer() *EventCharacterTransfer {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_CharacterTransfer); ok {
+			return x.CharacterTransfer
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetGroup() *EventGroup {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_Group); ok {
+			return x.Group
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetRelationship() *EventRelationship {
+	if x != nil {
+		if x, ok := x.Msg.(*Event_Relationship); ok {
+			return x.Relationship
+		}
+	}
+	return nil
+}
+
 type isEvent_Msg interface {
 	isEvent_Msg()
 }
@@ -177,6 +277,46 @@ type Event_PlayerCharacterOrgaEdit struct {
 	PlayerCharacterOrgaEdit *EventPlayerCharacterOrgaEdit `protobuf:"bytes,8,opt,name=PlayerCharacterOrgaEdit,proto3,oneof"`
 }
 
+type Event_Edition struct {
+	Edition *EventEdition `protobuf:"bytes,9,opt,name=Edition,proto3,oneof"`
+}
+
+type Event_Univers struct {
+	Univers *EventUnivers `protobuf:"bytes,10,opt,name=Univers,proto3,oneof"`
+}
+
+type Event_RenameActor struct {
+	RenameActor *EventRenameActor `protobuf:"bytes,11,opt,name=RenameActor,proto3,oneof"`
+}
+
+type Event_ActorActivation struct {
+	ActorActivation *EventActorActivation `protobuf:"bytes,12,opt,name=ActorActivation,proto3,oneof"`
+}
+
+type Event_PlayerErasure struct {
+	PlayerErasure *EventPlayerErasure `protobuf:"bytes,13,opt,name=PlayerErasure,proto3,oneof"`
+}
+
+type Event_Erased struct {
+	Erased *EventErased `protobuf:"bytes,14,opt,name=Erased,proto3,oneof"`
+}
+
+type Event_CharacterReview struct {
+	CharacterReview *EventCharacterReview `protobuf:"bytes,15,opt,name=CharacterReview,proto3,oneof"`
+}
+
+type Event_CharacterTransfer struct {
+	CharacterTransfer *EventCharacterTransfer `protobuf:"bytes,16,opt,name=CharacterTransfer,proto3,oneof"`
+}
+
+type Event_Group struct {
+	Group *EventGroup `protobuf:"bytes,17,opt,name=Group,proto3,oneof"`
+}
+
+type Event_Relationship struct {
+	Relationship *EventRelationship `protobuf:"bytes,18,opt,name=Relationship,proto3,oneof"`
+}
+
 func (*Event_Permission) isEvent_Msg() {}
 
 func (*Event_SeedPlayer) isEvent_Msg() {}
@@ -191,9 +331,40 @@ func (*Event_Reset_) isEvent_Msg() {}
 
 func (*Event_PlayerCharacterOrgaEdit) isEvent_Msg() {}
 
+func (*Event_Edition) isEvent_Msg() {}
+
+func (*Event_Univers) isEvent_Msg() {}
+
+func (*Event_RenameActor) isEvent_Msg() {}
+
+func (*Event_ActorActivation) isEvent_Msg() {}
+
+func (*Event_PlayerErasure) isEvent_Msg() {}
+
+func (*Event_Erased) isEvent_Msg() {}
+
+func (*Event_CharacterReview) isEvent_Msg() {}
+
+func (*Event_CharacterTransfer) isEvent_Msg() {}
+
+func (*Event_Group) isEvent_Msg() {}
+
+func (*Event_Relationship) isEvent_Msg() {}
+
 type Events struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Events []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	// idempotencyKey identifies the batch across retries, a repeated batch
+	// gets the results of the first submission.
+	IdempotencyKey string `protobuf:"bytes,2,opt,name=idempotencyKey,proto3" json:"idempotencyKey,omitempty"`
+	// actorId is the actor the fetched events are projected for.
+	ActorId int64 `protobuf:"varint,3,opt,name=actorId,proto3" json:"actorId,omitempty"`
+	// headTs and head are the ts of the last event of the log and the hash
+	// the next event chains to, headSignature is their ES256 signature by the
+	// head key of the server. They are only set when the server has one.
+	HeadTs        int64  `protobuf:"varint,4,opt,name=headTs,proto3" json:"headTs,omitempty"`
+	Head          []byte `protobuf:"bytes,5,opt,name=head,proto3" json:"head,omitempty"`
+	HeadSignature []byte `protobuf:"bytes,6,opt,name=headSignature,proto3" json:"headSignature,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -235,6 +406,41 @@ func (x *Events) GetEvents() []*Event {
 	return nil
 }
 
+func (x *Events) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *Events) GetActorId() int64 {
+	if x != nil {
+		return x.ActorId
+	}
+	return 0
+}
+
+func (x *Events) GetHeadTs() int64 {
+	if x != nil {
+		return x.HeadTs
+	}
+	return 0
+}
+
+func (x *Events) GetHead() []byte {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
+func (x *Events) GetHeadSignature() []byte {
+	if x != nil {
+		return x.HeadSignature
+	}
+	return nil
+}
+
 var File_event_proto protoreflect.FileDescriptor
 
 var file_event_proto_rawDesc = string([]byte{
@@ -247,44 +453,106 @@ var file_event_proto_rawDesc = string([]byte{
 	0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x16, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x61,
 	0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x20, 0x70, 0x6c,
 	0x61, 0x79, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x5f, 0x6f,
-	0x72, 0x67, 0x61, 0x5f, 0x65, 0x64, 0x69, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe3,
-	0x03, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x74, 0x73, 0x12, 0x3c, 0x0a, 0x0a, 0x50, 0x65, 0x72, 0x6d,
-	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74,
-	0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x65,
-	0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0a, 0x50, 0x65, 0x72, 0x6d,
-	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x3c, 0x0a, 0x0a, 0x53, 0x65, 0x65, 0x64, 0x50, 0x6c,
-	0x61, 0x79, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x68, 0x65,
-	0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x65, 0x64,
-	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x48, 0x00, 0x52, 0x0a, 0x53, 0x65, 0x65, 0x64, 0x50, 0x6c,
-	0x61, 0x79, 0x65, 0x72, 0x12, 0x39, 0x0a, 0x09, 0x53, 0x65, 0x65, 0x64, 0x41, 0x63, 0x74, 0x6f,
-	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65,
-	0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x65, 0x64, 0x41, 0x63, 0x74,
-	0x6f, 0x72, 0x48, 0x00, 0x52, 0x09, 0x53, 0x65, 0x65, 0x64, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12,
-	0x42, 0x0a, 0x0c, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65,
-	0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x65, 0x72,
-	0x73, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0c, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x65, 0x72,
-	0x73, 0x6f, 0x6e, 0x12, 0x4b, 0x0a, 0x0f, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61,
-	0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74,
-	0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c,
-	0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x48, 0x00, 0x52,
-	0x0f, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72,
-	0x12, 0x16, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x48,
-	0x00, 0x52, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x63, 0x0a, 0x17, 0x50, 0x6c, 0x61, 0x79,
+	0x72, 0x67, 0x61, 0x5f, 0x65, 0x64, 0x69, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0d,
+	0x65, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0d, 0x75,
+	0x6e, 0x69, 0x76, 0x65, 0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x12, 0x72, 0x65,
+	0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x16, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x14, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x5f, 0x65, 0x72, 0x61, 0x73, 0x75, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0c,
+	0x65, 0x72, 0x61, 0x73, 0x65, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x16, 0x63, 0x68,
+	0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x18, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x5f,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0b,
+	0x67, 0x72, 0x6f, 0x75, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x12, 0x72, 0x65, 0x6c,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xe7, 0x08, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x74, 0x73, 0x12, 0x3c, 0x0a, 0x0a, 0x50, 0x65, 0x72,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50,
+	0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0a, 0x50, 0x65, 0x72,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x3c, 0x0a, 0x0a, 0x53, 0x65, 0x65, 0x64, 0x50,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x68,
+	0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x65,
+	0x64, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x48, 0x00, 0x52, 0x0a, 0x53, 0x65, 0x65, 0x64, 0x50,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x12, 0x39, 0x0a, 0x09, 0x53, 0x65, 0x65, 0x64, 0x41, 0x63, 0x74,
+	0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65,
+	0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x65, 0x64, 0x41, 0x63,
+	0x74, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x09, 0x53, 0x65, 0x65, 0x64, 0x41, 0x63, 0x74, 0x6f, 0x72,
+	0x12, 0x42, 0x0a, 0x0c, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x65, 0x72, 0x73, 0x6f, 0x6e,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70,
+	0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x65,
+	0x72, 0x73, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0c, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x65,
+	0x72, 0x73, 0x6f, 0x6e, 0x12, 0x4b, 0x0a, 0x0f, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68,
+	0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e,
+	0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x48, 0x00,
+	0x52, 0x0f, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65,
+	0x72, 0x12, 0x16, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x00, 0x52, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x63, 0x0a, 0x17, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x4f, 0x72, 0x67, 0x61,
+	0x45, 0x64, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x74, 0x68, 0x65,
+	0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x79,
 	0x65, 0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x4f, 0x72, 0x67, 0x61, 0x45,
-	0x64, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x74, 0x68, 0x65, 0x6b,
-	0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65,
-	0x72, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x4f, 0x72, 0x67, 0x61, 0x45, 0x64,
-	0x69, 0x74, 0x48, 0x00, 0x52, 0x17, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61, 0x72,
-	0x61, 0x63, 0x74, 0x65, 0x72, 0x4f, 0x72, 0x67, 0x61, 0x45, 0x64, 0x69, 0x74, 0x42, 0x05, 0x0a,
-	0x03, 0x6d, 0x73, 0x67, 0x22, 0x32, 0x0a, 0x06, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x28,
-	0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x64, 0x69, 0x74, 0x48, 0x00, 0x52, 0x17, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x68, 0x61,
+	0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x4f, 0x72, 0x67, 0x61, 0x45, 0x64, 0x69, 0x74, 0x12, 0x33,
+	0x0a, 0x07, 0x45, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x45, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x07, 0x45, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x07, 0x55, 0x6e, 0x69, 0x76, 0x65, 0x72, 0x73, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72,
+	0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x55, 0x6e, 0x69, 0x76, 0x65, 0x72, 0x73, 0x48, 0x00, 0x52,
+	0x07, 0x55, 0x6e, 0x69, 0x76, 0x65, 0x72, 0x73, 0x12, 0x3f, 0x0a, 0x0b, 0x52, 0x65, 0x6e, 0x61,
+	0x6d, 0x65, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52,
+	0x65, 0x6e, 0x61, 0x6d, 0x65, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x0b, 0x52, 0x65,
+	0x6e, 0x61, 0x6d, 0x65, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x4b, 0x0a, 0x0f, 0x41, 0x63, 0x74,
+	0x6f, 0x72, 0x41, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x41, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0f, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x41, 0x63, 0x74, 0x69,
+	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x45, 0x0a, 0x0d, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x45, 0x72, 0x61, 0x73, 0x75, 0x72, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x45, 0x72, 0x61, 0x73, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x0d,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x45, 0x72, 0x61, 0x73, 0x75, 0x72, 0x65, 0x12, 0x30, 0x0a,
+	0x06, 0x45, 0x72, 0x61, 0x73, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x45,
+	0x72, 0x61, 0x73, 0x65, 0x64, 0x48, 0x00, 0x52, 0x06, 0x45, 0x72, 0x61, 0x73, 0x65, 0x64, 0x12,
+	0x4b, 0x0a, 0x0f, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65,
+	0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63,
+	0x74, 0x65, 0x72, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x48, 0x00, 0x52, 0x0f, 0x43, 0x68, 0x61,
+	0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x51, 0x0a, 0x11,
+	0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65,
+	0x72, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65,
+	0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74,
+	0x65, 0x72, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x48, 0x00, 0x52, 0x11, 0x43, 0x68,
+	0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x12,
+	0x2d, 0x0a, 0x05, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
 	0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74,
-	0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x62, 0x65, 0x6e, 0x61, 0x75, 0x6d, 0x2f, 0x74,
-	0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x47, 0x72, 0x6f, 0x75, 0x70, 0x48, 0x00, 0x52, 0x05, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x42,
+	0x0a, 0x0c, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72,
+	0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68,
+	0x69, 0x70, 0x48, 0x00, 0x52, 0x0c, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68,
+	0x69, 0x70, 0x42, 0x05, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x22, 0xc6, 0x01, 0x0a, 0x06, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72,
+	0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x26,
+	0x0a, 0x0e, 0x69, 0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4b, 0x65, 0x79,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x69, 0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65,
+	0x6e, 0x63, 0x79, 0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x49,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x49, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x54, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x54, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x65, 0x61, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x65, 0x61, 0x64, 0x12, 0x24, 0x0a, 0x0d,
+	0x68, 0x65, 0x61, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0d, 0x68, 0x65, 0x61, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x65, 0x62, 0x65, 0x6e, 0x61, 0x75, 0x6d, 0x2f, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70,
+	0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -309,20 +577,40 @@ var file_event_proto_goTypes = []any{
 	(*EventPlayerPerson)(nil),            // 5: thekeeper.EventPlayerPerson
 	(*EventPlayerCharacter)(nil),         // 6: thekeeper.EventPlayerCharacter
 	(*EventPlayerCharacterOrgaEdit)(nil), // 7: thekeeper.EventPlayerCharacterOrgaEdit
+	(*EventEdition)(nil),                 // 8: thekeeper.EventEdition
+	(*EventUnivers)(nil),                 // 9: thekeeper.EventUnivers
+	(*EventRenameActor)(nil),             // 10: thekeeper.EventRenameActor
+	(*EventActorActivation)(nil),         // 11: thekeeper.EventActorActivation
+	(*EventPlayerErasure)(nil),           // 12: thekeeper.EventPlayerErasure
+	(*EventErased)(nil),                  // 13: thekeeper.EventErased
+	(*EventCharacterReview)(nil),         // 14: thekeeper.EventCharacterReview
+	(*EventCharacterTransfer)(nil),       // 15: thekeeper.EventCharacterTransfer
+	(*EventGroup)(nil),                   // 16: thekeeper.EventGroup
+	(*EventRelationship)(nil),            // 17: thekeeper.EventRelationship
 }
 var file_event_proto_depIdxs = []int32{
-	2, // 0: thekeeper.Event.Permission:type_name -> thekeeper.EventPermission
-	3, // 1: thekeeper.Event.SeedPlayer:type_name -> thekeeper.EventSeedPlayer
-	4, // 2: thekeeper.Event.SeedActor:type_name -> thekeeper.EventSeedActor
-	5, // 3: thekeeper.Event.PlayerPerson:type_name -> thekeeper.EventPlayerPerson
-	6, // 4: thekeeper.Event.PlayerCharacter:type_name -> thekeeper.EventPlayerCharacter
-	7, // 5: thekeeper.Event.PlayerCharacterOrgaEdit:type_name -> thekeeper.EventPlayerCharacterOrgaEdit
-	0, // 6: thekeeper.Events.events:type_name -> thekeeper.Event
-	7, // [7:7] is the sub-list for method output_type
-	7, // [7:7] is the sub-list for method input_type
-	7, // [7:7] is the sub-list for extension type_name
-	7, // [7:7] is the sub-list for extension extendee
-	0, // [0:7] is the sub-list for field type_name
+	2,  // 0: thekeeper.Event.Permission:type_name -> thekeeper.EventPermission
+	3,  // 1: thekeeper.Event.SeedPlayer:type_name -> thekeeper.EventSeedPlayer
+	4,  // 2: thekeeper.Event.SeedActor:type_name -> thekeeper.EventSeedActor
+	5,  // 3: thekeeper.Event.PlayerPerson:type_name -> thekeeper.EventPlayerPerson
+	6,  // 4: thekeeper.Event.PlayerCharacter:type_name -> thekeeper.EventPlayerCharacter
+	7,  // 5: thekeeper.Event.PlayerCharacterOrgaEdit:type_name -> thekeeper.EventPlayerCharacterOrgaEdit
+	8,  // 6: thekeeper.Event.Edition:type_name -> thekeeper.EventEdition
+	9,  // 7: thekeeper.Event.Univers:type_name -> thekeeper.EventUnivers
+	10, // 8: thekeeper.Event.RenameActor:type_name -> thekeeper.EventRenameActor
+	11, // 9: thekeeper.Event.ActorActivation:type_name -> thekeeper.EventActorActivation
+	12, // 10: thekeeper.Event.PlayerErasure:type_name -> thekeeper.EventPlayerErasure
+	13, // 11: thekeeper.Event.Erased:type_name -> thekeeper.EventErased
+	14, // 12: thekeeper.Event.CharacterReview:type_name -> thekeeper.EventCharacterReview
+	15, // 13: thekeeper.Event.CharacterTransfer:type_name -> thekeeper.EventCharacterTransfer
+	16, // 14: thekeeper.Event.Group:type_name -> thekeeper.EventGroup
+	17, // 15: thekeeper.Event.Relationship:type_name -> thekeeper.EventRelationship
+	0,  // 16: thekeeper.Events.events:type_name -> thekeeper.Event
+	17, // [17:17] is the sub-list for method output_type
+	17, // [17:17] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_event_proto_init() }
@@ -336,6 +624,16 @@ func file_event_proto_init() {
 	file_player_person_proto_init()
 	file_player_character_proto_init()
 	file_player_character_orga_edit_proto_init()
+	file_edition_proto_init()
+	file_univers_proto_init()
+	file_rename_actor_proto_init()
+	file_actor_activation_proto_init()
+	file_player_erasure_proto_init()
+	file_erased_proto_init()
+	file_character_review_proto_init()
+	file_character_transfer_proto_init()
+	file_group_proto_init()
+	file_relationship_proto_init()
 	file_event_proto_msgTypes[0].OneofWrappers = []any{
 		(*Event_Permission)(nil),
 		(*Event_SeedPlayer)(nil),
@@ -344,6 +642,16 @@ func file_event_proto_init() {
 		(*Event_PlayerCharacter)(nil),
 		(*Event_Reset_)(nil),
 		(*Event_PlayerCharacterOrgaEdit)(nil),
+		(*Event_Edition)(nil),
+		(*Event_Univers)(nil),
+		(*Event_RenameActor)(nil),
+		(*Event_ActorActivation)(nil),
+		(*Event_PlayerErasure)(nil),
+		(*Event_Erased)(nil),
+		(*Event_CharacterReview)(nil),
+		(*Event_CharacterTransfer)(nil),
+		(*Event_Group)(nil),
+		(*Event_Relationship)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
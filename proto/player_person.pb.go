@@ -41,8 +41,15 @@ type EventPlayerPerson struct {
 	SituationToAvoid              string                 `protobuf:"bytes,16,opt,name=situationToAvoid,proto3" json:"situationToAvoid,omitempty"`
 	InscriptionType               string                 `protobuf:"bytes,17,opt,name=inscriptionType,proto3" json:"inscriptionType,omitempty"`
 	PictureRights                 bool                   `protobuf:"varint,18,opt,name=pictureRights,proto3" json:"pictureRights,omitempty"`
-	unknownFields                 protoimpl.UnknownFields
-	sizeCache                     protoimpl.SizeCache
+	// expectedVersion is the ts of the last accepted EventPlayerPerson of the
+	// player the edit is based on, 0 skips the check.
+	ExpectedVersion int64 `protobuf:"varint,19,opt,name=expectedVersion,proto3" json:"expectedVersion,omitempty"`
+	// medicalRedacted is set on the registrations sent without health and
+	// emergencyContact to the actors who may not read them. An edit keeping
+	// it set keeps the medical fields of the previous registration.
+	MedicalRedacted bool `protobuf:"varint,20,opt,name=medicalRedacted,proto3" json:"medicalRedacted,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *EventPlayerPerson) Reset() {
@@ -201,12 +208,26 @@ func (x *EventPlayerPerson) GetPictureRights() bool {
 	return false
 }
 
+func (x *EventPlayerPerson) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *EventPlayerPerson) GetMedicalRedacted() bool {
+	if x != nil {
+		return x.MedicalRedacted
+	}
+	return false
+}
+
 var File_player_person_proto protoreflect.FileDescriptor
 
 var file_player_person_proto_rawDesc = string([]byte{
 	0x0a, 0x13, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72,
-	0x22, 0xc1, 0x05, 0x0a, 0x11, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x22, 0x95, 0x06, 0x0a, 0x11, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
 	0x50, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
 	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
 	0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
@@ -250,10 +271,15 @@ var file_player_person_proto_rawDesc = string([]byte{
 	0x6e, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x12, 0x24,
 	0x0a, 0x0d, 0x70, 0x69, 0x63, 0x74, 0x75, 0x72, 0x65, 0x52, 0x69, 0x67, 0x68, 0x74, 0x73, 0x18,
 	0x12, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x70, 0x69, 0x63, 0x74, 0x75, 0x72, 0x65, 0x52, 0x69,
-	0x67, 0x68, 0x74, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x65, 0x62, 0x65, 0x6e, 0x61, 0x75, 0x6d, 0x2f, 0x74, 0x68, 0x65, 0x6b, 0x65,
-	0x65, 0x70, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x67, 0x68, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x13, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x65,
+	0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x28,
+	0x0a, 0x0f, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x6c, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x65,
+	0x64, 0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x6c,
+	0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x65, 0x64, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x62, 0x65, 0x6e, 0x61, 0x75, 0x6d, 0x2f, 0x74,
+	0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.4
+// 	protoc        v6.30.2
+// source: relationship.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EventRelationship links two characters. An event with the relationshipId of
+// an existing relationship redefines it.
+type EventRelationship struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RelationshipId  string                 `protobuf:"bytes,1,opt,name=relationshipId,proto3" json:"relationshipId,omitempty"`
+	FromCharacterId string                 `protobuf:"bytes,2,opt,name=fromCharacterId,proto3" json:"fromCharacterId,omitempty"`
+	ToCharacterId   string                 `protobuf:"bytes,3,opt,name=toCharacterId,proto3" json:"toCharacterId,omitempty"`
+	// type is the kind of relationship: siblings, rivals, debt...
+	Type        string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Description string `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// visibleToFrom and visibleToTo tell whether the player of each character
+	// knows about the relationship.
+	VisibleToFrom bool `protobuf:"varint,6,opt,name=visibleToFrom,proto3" json:"visibleToFrom,omitempty"`
+	VisibleToTo   bool `protobuf:"varint,7,opt,name=visibleToTo,proto3" json:"visibleToTo,omitempty"`
+	// remove deletes the relationship.
+	Remove        bool `protobuf:"varint,8,opt,name=remove,proto3" json:"remove,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventRelationship) Reset() {
+	*x = EventRelationship{}
+	mi := &file_relationship_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventRelationship) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventRelationship) ProtoMessage() {}
+
+func (x *EventRelationship) ProtoReflect() protoreflect.Message {
+	mi := &file_relationship_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventRelationship.ProtoReflect.Descriptor instead.
+func (*EventRelationship) Descriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventRelationship) GetRelationshipId() string {
+	if x != nil {
+		return x.RelationshipId
+	}
+	return ""
+}
+
+func (x *EventRelationship) GetFromCharacterId() string {
+	if x != nil {
+		return x.FromCharacterId
+	}
+	return ""
+}
+
+func (x *EventRelationship) GetToCharacterId() string {
+	if x != nil {
+		return x.ToCharacterId
+	}
+	return ""
+}
+
+func (x *EventRelationship) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *EventRelationship) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *EventRelationship) GetVisibleToFrom() bool {
+	if x != nil {
+		return x.VisibleToFrom
+	}
+	return false
+}
+
+func (x *EventRelationship) GetVisibleToTo() bool {
+	if x != nil {
+		return x.VisibleToTo
+	}
+	return false
+}
+
+func (x *EventRelationship) GetRemove() bool {
+	if x != nil {
+		return x.Remove
+	}
+	return false
+}
+
+var File_relationship_proto protoreflect.FileDescriptor
+
+var file_relationship_proto_rawDesc = string([]byte{
+	0x0a, 0x12, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65, 0x70, 0x65, 0x72, 0x22,
+	0xa1, 0x02, 0x0a, 0x11, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x68, 0x69, 0x70, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x68, 0x69, 0x70, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72,
+	0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x49, 0x64, 0x12, 0x28, 0x0a,
+	0x0f, 0x66, 0x72, 0x6f, 0x6d, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x49, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x66, 0x72, 0x6f, 0x6d, 0x43, 0x68, 0x61, 0x72,
+	0x61, 0x63, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x74, 0x6f, 0x43, 0x68, 0x61,
+	0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x74, 0x6f, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x24, 0x0a, 0x0d, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x54, 0x6f,
+	0x46, 0x72, 0x6f, 0x6d, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x76, 0x69, 0x73, 0x69,
+	0x62, 0x6c, 0x65, 0x54, 0x6f, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x76, 0x69, 0x73,
+	0x69, 0x62, 0x6c, 0x65, 0x54, 0x6f, 0x54, 0x6f, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b,
+	0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x54, 0x6f, 0x54, 0x6f, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x65, 0x6d,
+	0x6f, 0x76, 0x65, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x65, 0x62, 0x65, 0x6e, 0x61, 0x75, 0x6d, 0x2f, 0x74, 0x68, 0x65, 0x6b, 0x65, 0x65,
+	0x70, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
+
+var (
+	file_relationship_proto_rawDescOnce sync.Once
+	file_relationship_proto_rawDescData []byte
+)
+
+func file_relationship_proto_rawDescGZIP() []byte {
+	file_relationship_proto_rawDescOnce.Do(func() {
+		file_relationship_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_relationship_proto_rawDesc), len(file_relationship_proto_rawDesc)))
+	})
+	return file_relationship_proto_rawDescData
+}
+
+var file_relationship_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_relationship_proto_goTypes = []any{
+	(*EventRelationship)(nil), // 0: thekeeper.EventRelationship
+}
+var file_relationship_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_relationship_proto_init() }
+func file_relationship_proto_init() {
+	if File_relationship_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_relationship_proto_rawDesc), len(file_relationship_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_relationship_proto_goTypes,
+		DependencyIndexes: file_relationship_proto_depIdxs,
+		MessageInfos:      file_relationship_proto_msgTypes,
+	}.Build()
+	File_relationship_proto = out.File
+	file_relationship_proto_goTypes = nil
+	file_relationship_proto_depIdxs = nil
+}
@@ -0,0 +1,22 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file actor_activation.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file actor_activation.proto.
+ */
+export const file_actor_activation =
+  /*@__PURE__*/
+  fileDesc(
+    "ChZhY3Rvcl9hY3RpdmF0aW9uLnByb3RvEgl0aGVrZWVwZXIiOgoURXZlbnRBY3RvckFjdGl2YXRpb24SDgoGaGFuZGxlGAEgASgJEhIKCnJlYWN0aXZhdGUYAiABKAhCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+  );
+
+/**
+ * Describes the message thekeeper.EventActorActivation.
+ * Use `create(EventActorActivationSchema)` to create a new message.
+ */
+export const EventActorActivationSchema =
+  /*@__PURE__*/
+  messageDesc(file_actor_activation, 0);
@@ -4,9 +4,12 @@
 import * as jose from "jose";
 // @ts-ignore
 import { create, toJson, toBinary, fromBinary } from "@bufbuild/protobuf";
+// @ts-ignore
+import { BinaryReader, WireType } from "@bufbuild/protobuf/wire";
 import { EventsSchema } from "./event_pb.js";
 import { EventPlayerPersonSchema } from "./player_person_pb.js";
 import { EventPlayerCharacterSchema } from "./player_character_pb.js";
+import { EventPlayerCharacterOrgaEditSchema } from "./player_character_orga_edit_pb.js";
 
 /**
  *
@@ -42,6 +45,10 @@ function newData() {
     handle: "",
     players: {},
     characters: {},
+    orgaEdits: {},
+    reviews: {},
+    groups: {},
+    relationships: {},
   };
 }
 
@@ -98,15 +105,18 @@ async function init() {
         },
       },
     ],
+    idempotencyKey: window.crypto.randomUUID(),
   });
 
+  const body = toBinary(EventsSchema, seed);
+
   const response = await fetch(`${globalThis.env.thekeeperURL}/state`, {
     method: "POST",
     headers: {
-      Authorization: await auth(keypair.private, keypair.public),
+      Authorization: await auth(keypair.private, keypair.public, body),
       "Content-Type": "application/x-protobuf",
     },
-    body: toBinary(EventsSchema, seed),
+    body: body,
   });
 
   const jsonResponse = await response.json();
@@ -153,6 +163,7 @@ async function init() {
  * @property {string}   situationToAvoid
  * @property {string}   inscriptionType
  * @property {boolean}  pictureRights
+ * @property {boolean}  [medicalRedacted] set when the server withheld the medical fields
  */
 
 /**
@@ -176,6 +187,7 @@ async function init() {
  * @property {Object.<string,number>} inventory
  * @property {Characteristics}        characteristics
  * @property {string}                 description
+ * @property {string}                 [groupId] group the character is a member of, see Data.groups
  */
 
 /**
@@ -203,7 +215,13 @@ async function init() {
  * @property {Object.<string, {handle: string, personal?: InformationsForm, characters: string[]}>} players
  * @property {Object.<string, CharacterForm>} characters
  * @property {string} handle
+ * @property {string} [actorId] actor the events are fetched for
  * @property {string} [permission]
+ * @property {boolean} [medical] set when the actor may read the medical fields
+ * @property {Object.<string, {state: string, comment: string}>} [reviews] approval state of the characters, a character without one is a draft
+ * @property {Object.<string, {name: string, description: string, capacity: number, leaderCharacterId: string}>} [groups] groups the characters join through their groupId
+ * @property {Object.<string, {fromCharacterId: string, toCharacterId: string, type: string, description: string}>} [relationships] relationships between characters, players only get the ones they know about
+ * @property {boolean} [stale] set when the events must be fetched again from the start
  */
 
 /**
@@ -284,26 +302,69 @@ async function sync(state, reset) {
     new Uint8Array(await response.arrayBuffer()),
   );
 
+  if (globalThis.env.thekeeperHeadKey) {
+    await verifyHead(msg);
+  }
+
+  state.data.actorId = msg.actorId.toString();
+
   msg.events.forEach(
     function (
       /** @type {{ msg: { case: any; value: any; }; ts: number; }} */ event,
     ) {
-      processEvent(state.data, event.msg.case, event.msg.value, reset);
+      processEvent(state.data, event.msg.case, event.msg.value, reset, event.ts);
       state.cursor = event.ts;
     },
   );
 
+  if (state.data.stale) {
+    await sync(state, true);
+    return;
+  }
+
   localStorage.setItem("cursor", state.cursor.toString());
   localStorage.setItem("data", JSON.stringify(state.data));
 }
 
+/**
+ * Checks that the head of the log sent with the events is signed by the head
+ * key of the server.
+ * @param {{ headTs: bigint; head: Uint8Array; headSignature: Uint8Array; }} msg
+ */
+async function verifyHead(msg) {
+  const key = await window.crypto.subtle.importKey(
+    "jwk",
+    globalThis.env.thekeeperHeadKey,
+    { name: "ECDSA", namedCurve: "P-256" },
+    false,
+    ["verify"],
+  );
+
+  // The big endian ts of the last event followed by the hash.
+  const message = new Uint8Array(8 + msg.head.length);
+  new DataView(message.buffer).setBigInt64(0, BigInt(msg.headTs));
+  message.set(msg.head, 8);
+
+  const valid = await window.crypto.subtle.verify(
+    { name: "ECDSA", hash: "SHA-256" },
+    key,
+    msg.headSignature,
+    message,
+  );
+
+  if (!valid) {
+    throw new Error("the head of the log is not signed by the server");
+  }
+}
+
 /**
  * @param {Data} data
  * @param {any} eventType
  * @param {any} eventValue
  * @params {boolean} reset
+ * @param {bigint} ts
  */
-function processEvent(data, eventType, eventValue, reset) {
+function processEvent(data, eventType, eventValue, reset, ts) {
   switch (eventType) {
     case "SeedPlayer":
       data.players[eventValue.playerId] = {
@@ -315,12 +376,46 @@ function processEvent(data, eventType, eventValue, reset) {
     case "SeedActor":
       data.handle = eventValue.handle;
 
+      break;
+    case "RenameActor":
+      if (data.handle === eventValue.handle) {
+        data.handle = eventValue.newHandle;
+      }
+
+      for (const player of Object.values(data.players)) {
+        if (player.handle === eventValue.handle) {
+          player.handle = eventValue.newHandle;
+        }
+      }
+
+      break;
+    case "ActorActivation":
+      break;
+    case "PlayerErasure":
+      if (data.players[eventValue.playerId]) {
+        delete data.players[eventValue.playerId].personal;
+        delete data.players[eventValue.playerId].personalVersion;
+      }
+
+      break;
+    case "Erased":
       break;
     case "Permission":
-      data.permission = eventValue.permission;
+      // The orgas get the roles of the other actors too.
+      if (eventValue.actorId.toString() !== data.actorId) {
+        break;
+      }
+
+      // The medical fields are granted next to the role.
+      if (eventValue.permission === "medical") {
+        data.medical = !eventValue.revoke;
+      } else {
+        data.permission = eventValue.revoke ? undefined : eventValue.permission;
+      }
 
       break;
     case "Reset":
+    case "Edition":
       if (!reset) {
         localStorage.setItem("cursor", "-1");
         window.location.href = window.location.href;
@@ -336,6 +431,8 @@ function processEvent(data, eventType, eventValue, reset) {
         eventValue,
         { alwaysEmitImplicit: true },
       );
+      // Version the next edit is based on, see expectedVersion.
+      data.players[eventValue.playerId].personalVersion = ts.toString();
 
       break;
     case "PlayerCharacter":
@@ -346,8 +443,10 @@ function processEvent(data, eventType, eventValue, reset) {
           alwaysEmitImplicit: true,
         },
       );
+      data.characters[eventValue.characterId].version = ts.toString();
 
       if (
+        data.players[eventValue.playerId] &&
         data.players[eventValue.playerId].characters.indexOf(
           eventValue.characterId,
         ) === -1
@@ -357,20 +456,133 @@ function processEvent(data, eventType, eventValue, reset) {
         );
       }
 
+      break;
+    case "PlayerCharacterOrgaEdit":
+      // Players only receive the fields they may see, see playerOrgaEdit.
+      data.orgaEdits ??= {};
+      data.orgaEdits[eventValue.characterId] = toJson(
+        EventPlayerCharacterOrgaEditSchema,
+        eventValue,
+        { alwaysEmitImplicit: true },
+      );
+
+      break;
+    case "CharacterTransfer": {
+      const receiving = data.players[eventValue.playerId];
+
+      if (!data.characters[eventValue.characterId]) {
+        // The history of a character handed over to us precedes the cursor.
+        if (receiving && !reset) {
+          data.stale = true;
+        }
+
+        break;
+      }
+
+      for (const player of Object.values(data.players)) {
+        player.characters = player.characters.filter(
+          (characterId) => characterId !== eventValue.characterId,
+        );
+      }
+
+      if (receiving) {
+        data.characters[eventValue.characterId].playerId = eventValue.playerId;
+        receiving.characters.push(eventValue.characterId);
+      } else {
+        delete data.characters[eventValue.characterId];
+        delete data.orgaEdits?.[eventValue.characterId];
+        delete data.reviews?.[eventValue.characterId];
+      }
+
+      break;
+    }
+    case "Group":
+      data.groups ??= {};
+      data.groups[eventValue.groupId] = {
+        name: eventValue.name,
+        description: eventValue.description,
+        capacity: eventValue.capacity,
+        leaderCharacterId: eventValue.leaderCharacterId,
+      };
+
+      break;
+    case "Relationship":
+      data.relationships ??= {};
+
+      if (eventValue.remove) {
+        delete data.relationships[eventValue.relationshipId];
+      } else {
+        data.relationships[eventValue.relationshipId] = {
+          fromCharacterId: eventValue.fromCharacterId,
+          toCharacterId: eventValue.toCharacterId,
+          type: eventValue.type,
+          description: eventValue.description,
+        };
+      }
+
+      break;
+    case "CharacterReview":
+      data.reviews ??= {};
+      data.reviews[eventValue.characterId] = {
+        state: eventValue.state,
+        comment: eventValue.comment,
+      };
+
       break;
     default:
       console.log(`unknown event ${eventType} ${eventValue}`);
   }
 }
 
+/**
+ * Hash of the digests of the events of an Events payload. Unlike the hash of
+ * the payload, the server can still check it once one of the events is
+ * erased.
+ * @param {Uint8Array} payload
+ * @returns {Promise<string>}
+ */
+async function eventsHash(payload) {
+  const reader = new BinaryReader(payload);
+  const digests = [];
+
+  while (reader.pos < reader.len) {
+    const [fieldNo, wireType] = reader.tag();
+    if (fieldNo === 1 && wireType === WireType.LengthDelimited) {
+      digests.push(
+        new Uint8Array(
+          await window.crypto.subtle.digest("SHA-256", reader.bytes()),
+        ),
+      );
+    } else {
+      reader.skip(wireType);
+    }
+  }
+
+  const joined = new Uint8Array(digests.length * 32);
+  digests.forEach((digest, i) => joined.set(digest, i * 32));
+
+  return jose.base64url.encode(
+    new Uint8Array(await window.crypto.subtle.digest("SHA-256", joined)),
+  );
+}
+
 /**
  *
  * @param {CryptoKey} privateKey
  * @param {CryptoKey} publicKey
+ * @param {Uint8Array} [payload] Events body of the request, its hash and the hash of its events are signed with the token
  * @returns
  */
-async function auth(privateKey, publicKey) {
-  return await new jose.SignJWT({})
+async function auth(privateKey, publicKey, payload) {
+  const claims = {};
+  if (payload) {
+    claims.payload_hash = jose.base64url.encode(
+      new Uint8Array(await window.crypto.subtle.digest("SHA-256", payload)),
+    );
+    claims.events_hash = await eventsHash(payload);
+  }
+
+  return await new jose.SignJWT(claims)
     .setProtectedHeader({
       alg: "ES256",
       jwk: await window.crypto.subtle.exportKey("jwk", publicKey),
@@ -382,6 +594,112 @@ async function auth(privateKey, publicKey) {
     .sign(privateKey);
 }
 
+/**
+ * Whether the actor holds a role. The server checks what each role may do.
+ * @param {State | undefined | null} state
+ * @returns {boolean}
+ */
+function isOrga(state) {
+  return Boolean(state?.data.permission || state?.data.medical);
+}
+
+/**
+ * Warns the user when an edit was rejected because someone else edited the
+ * same record in the meantime.
+ * @param {{conflict?: boolean}[]} results
+ */
+function checkConflict(results) {
+  if (results.some((result) => result.conflict)) {
+    alert(
+      "Quelqu'un d'autre a modifié cette fiche entre-temps. Rechargez la page pour voir ses modifications avant de recommencer.",
+    );
+  }
+}
+
+const reviewLabels = {
+  draft: "Brouillon",
+  submitted: "Soumis",
+  "changes-requested": "Modifications demandées",
+  approved: "Validé",
+};
+
+/**
+ * Moves a character through the approval workflow, see ReviewTransitions.
+ * @param {State} state
+ * @param {string} characterId
+ * @param {string} reviewState
+ * @param {string} comment
+ */
+async function reviewCharacter(state, characterId, reviewState, comment) {
+  const payload = create(EventsSchema, {
+    events: [
+      {
+        msg: {
+          case: "CharacterReview",
+          value: {
+            characterId: characterId,
+            state: reviewState,
+            comment: comment,
+          },
+        },
+      },
+    ],
+    idempotencyKey: window.crypto.randomUUID(),
+  });
+
+  const body = toBinary(EventsSchema, payload);
+
+  const response = await fetch(`${globalThis.env.thekeeperURL}/state`, {
+    method: "POST",
+    headers: {
+      Authorization: await auth(state.keys.private, state.keys.public, body),
+      "Content-Type": "application/x-protobuf",
+    },
+    body: body,
+  });
+
+  const jsonResponse = await response.json();
+  if (jsonResponse[0].error) {
+    alert(jsonResponse[0].error);
+    return;
+  }
+
+  window.location.href = window.location.href;
+}
+
+/**
+ * Highlight the fields of a form a registration was rejected for
+ * @param {HTMLElement} formElement
+ * @param {{fields?: Object<string, string>}[]} results
+ * @returns {boolean} whether a field was highlighted
+ */
+function showFieldErrors(formElement, results) {
+  formElement
+    .querySelectorAll(".field-error")
+    .forEach((element) => element.classList.remove("field-error"));
+
+  let highlighted = false;
+
+  for (const result of results) {
+    for (const [field, reason] of Object.entries(result.fields ?? {})) {
+      const container = formElement.querySelector(
+        `label[for="${field}"]`,
+      )?.parentElement;
+      if (!container) {
+        continue;
+      }
+
+      container.classList.add("field-error");
+      container.setAttribute("title", reason);
+      highlighted = true;
+    }
+  }
+
+  formElement.querySelector(".field-error")?.scrollIntoView();
+
+  return highlighted;
+}
+
 /**
  * @callback attachSelectListenersCallback
  * @param {'select'|'unselect'} op
@@ -832,6 +1150,25 @@ async function personnage() {
 
   characterNameInputElement.value = formResult.name;
 
+  const groupSelectElement = /** @type {HTMLSelectElement | null} */ (
+    document.querySelector(".character-group__select")
+  );
+
+  if (groupSelectElement) {
+    Object.entries(state?.data.groups ?? {}).forEach(([groupId, group]) => {
+      const optionElement = document.createElement("option");
+      optionElement.value = groupId;
+      optionElement.textContent = group.name;
+      optionElement.title = group.description;
+      groupSelectElement.appendChild(optionElement);
+    });
+
+    groupSelectElement.value = formResult.groupId ?? "";
+    groupSelectElement.addEventListener("change", () => {
+      formResult.groupId = groupSelectElement.value;
+    });
+  }
+
   const /** @type {Skill[]} */ skills = univers
       .filter((entry) => entry.tags.includes("skill"))
       .map((skill) => {
@@ -899,7 +1236,7 @@ async function personnage() {
         return 0;
       });
 
-  if (state?.data.permission === "orga") {
+  if (isOrga(state)) {
     await personnageOrga(formResult, characteristics, universMap, skills);
   }
 
@@ -1817,24 +2154,32 @@ async function personnage() {
           skills: formResult.skills,
           description: formResult.description,
           inventory: formResult.inventory,
+          groupId: formResult.groupId ?? "",
+          expectedVersion: BigInt(
+            state.data.characters[characterId]?.version ?? 0,
+          ),
         },
       },
     });
 
     const payload = create(EventsSchema, {
       events: events,
+      idempotencyKey: window.crypto.randomUUID(),
     });
 
+    const body = toBinary(EventsSchema, payload);
+
     const response = await fetch(`${globalThis.env.thekeeperURL}/state`, {
       method: "POST",
       headers: {
-        Authorization: await auth(state.keys.private, state.keys.public),
+        Authorization: await auth(state.keys.private, state.keys.public, body),
         "Content-Type": "application/x-protobuf",
       },
-      body: toBinary(EventsSchema, payload),
+      body: body,
     });
 
     const jsonResponse = await response.json();
+    checkConflict(jsonResponse);
     if (jsonResponse[0].error) {
       throw jsonResponse[0].error;
     }
@@ -1844,6 +2189,22 @@ async function personnage() {
 
   let submitted = false;
 
+  // An approved character is frozen for its player until an orga requests
+  // changes.
+  if (
+    characterId &&
+    !isOrga(state) &&
+    state?.data.reviews?.[characterId]?.state === "approved"
+  ) {
+    formElement
+      ?.querySelectorAll('button[type="submit"]')
+      .forEach((button) => {
+        const submitButton = /** @type {HTMLButtonElement} */ (button);
+        submitButton.disabled = true;
+        submitButton.title = "Personnage validé par les orgas";
+      });
+  }
+
   if (formElement) {
     formElement.onsubmit = function () {
       if (submitted) {
@@ -1958,7 +2319,7 @@ async function index() {
         clone.querySelector(".player-card__sharelink")
       );
 
-      if (state.data.permission === "orga") {
+      if (isOrga(state)) {
         shareElement.textContent = "Lien de partage";
         shareElement.setAttribute("data-handle", player.handle);
       }
@@ -2027,11 +2388,100 @@ async function index() {
         characterPeek.push(universMap[character.group]?.label);
         characterPeek.push(universMap[character.race]?.label);
         characterPeek.push(universMap[character.vdv]?.label);
+        characterPeek.push(state.data.groups?.[character.groupId ?? ""]?.name);
 
         characterPeek = characterPeek.filter((n) => n);
 
         characterPeekElement.textContent = characterPeek.join(" - ");
 
+        const review = state.data.reviews?.[characterId] ?? {
+          state: "draft",
+          comment: "",
+        };
+
+        const characterReviewElement = /** @type {HTMLElement} */ (
+          characterClone.querySelector(
+            ".index__player__characters__character__review",
+          )
+        );
+        characterReviewElement.textContent = reviewLabels[review.state];
+        if (review.state === "changes-requested") {
+          characterReviewElement.title = review.comment;
+          characterReviewElement.textContent += ` : ${review.comment}`;
+        }
+
+        /** @type {[string, string][]} */
+        const actions = isOrga(state)
+          ? {
+              submitted: [
+                ["approved", "Valider"],
+                ["changes-requested", "Demander des modifications"],
+              ],
+              approved: [["changes-requested", "Demander des modifications"]],
+            }[review.state] || []
+          : {
+              draft: [["submitted", "Soumettre"]],
+              "changes-requested": [["submitted", "Soumettre"]],
+              submitted: [["draft", "Retirer"]],
+            }[review.state] || [];
+
+        const characterElement = /** @type {HTMLElement} */ (
+          characterClone.querySelector(
+            ".index__player__characters__character",
+          )
+        );
+
+        actions.forEach(([reviewState, label]) => {
+          const button = document.createElement("a");
+          button.classList.add("a-underline");
+          button.setAttribute("href", "#");
+          button.textContent = label;
+          button.addEventListener("click", (e) => {
+            e.preventDefault();
+
+            let comment = "";
+            if (reviewState === "changes-requested") {
+              comment = prompt("Quelles modifications demander ?") || "";
+              if (!comment) {
+                return;
+              }
+            }
+
+            reviewCharacter(state, characterId, reviewState, comment);
+          });
+
+          characterElement.append(" - ", button);
+        });
+
+        const relationships = Object.values(
+          state.data.relationships ?? {},
+        ).filter(
+          (relationship) =>
+            relationship.fromCharacterId === characterId ||
+            relationship.toCharacterId === characterId,
+        );
+
+        if (relationships.length > 0) {
+          const relationshipsElement = document.createElement("ul");
+          relationshipsElement.classList.add(
+            "index__player__characters__character__relationships",
+          );
+
+          relationships.forEach((relationship) => {
+            const otherId =
+              relationship.fromCharacterId === characterId
+                ? relationship.toCharacterId
+                : relationship.fromCharacterId;
+            const other = state.data.characters[otherId]?.name;
+
+            const liElement = document.createElement("li");
+            liElement.textContent = `${relationship.type}${other ? ` (${other})` : ""} : ${relationship.description}`;
+            relationshipsElement.appendChild(liElement);
+          });
+
+          characterElement.appendChild(relationshipsElement);
+        }
+
         charactersElement.prepend(characterClone);
       });
 
@@ -2074,7 +2524,7 @@ async function index() {
       });
   }
 
-  if (!state || state.data.permission !== "orga") {
+  if (!isOrga(state)) {
     const creationButton = document.createElement("a");
     creationButton.classList.add("character-creation-button");
     creationButton.classList.add("a-underline");
@@ -2110,7 +2560,7 @@ async function informations() {
   const playerId = url.searchParams.get("playerId");
 
   if (state) {
-    if (!playerId && state.data.permission === "orga") {
+    if (!playerId && isOrga(state)) {
       window.location.href = "/index.html";
       return;
     }
@@ -2214,6 +2664,19 @@ async function informations() {
     });
   });
 
+  // Without the medical capability the server keeps the medical fields of the
+  // registration, they cannot be edited here.
+  if (formResult.medicalRedacted) {
+    ["emergencyContact", "health"].forEach((name) => {
+      document.querySelectorAll(`[name="${name}"]`).forEach((input) => {
+        const field = /** @type{HTMLInputElement} */ (input);
+        field.disabled = true;
+        field.required = false;
+        field.placeholder = "Réservé à l'équipe médicale";
+      });
+    });
+  }
+
   const formElement = document.getElementById("form");
 
   /**
@@ -2263,24 +2726,41 @@ async function informations() {
           situationToAvoid: formResult.situationToAvoid,
           inscriptionType: formResult.inscriptionType,
           pictureRights: formResult.pictureRights,
+          medicalRedacted: formResult.medicalRedacted ?? false,
+          expectedVersion: BigInt(
+            state.data.players[playerId]?.personalVersion ?? 0,
+          ),
         },
       },
     });
 
     let seed = create(EventsSchema, {
       events: events,
+      idempotencyKey: window.crypto.randomUUID(),
     });
 
+    const body = toBinary(EventsSchema, seed);
+
     const response = await fetch(`${globalThis.env.thekeeperURL}/state`, {
       method: "POST",
       headers: {
-        Authorization: await auth(state.keys.private, state.keys.public),
+        Authorization: await auth(state.keys.private, state.keys.public, body),
         "Content-Type": "application/x-protobuf",
       },
-      body: toBinary(EventsSchema, seed),
+      body: body,
     });
 
     const jsonResponse = await response.json();
+    checkConflict(jsonResponse);
+    if (formElement && showFieldErrors(formElement, jsonResponse)) {
+      // The player exists once its SeedPlayer is accepted, the next
+      // attempts only send its registration.
+      if (!existingPlayerId && jsonResponse[0].status === "accepted") {
+        seededPlayerId = playerId;
+      }
+      submitted = false;
+      return;
+    }
     if (jsonResponse[0].error) {
       throw jsonResponse[0].error;
     }
@@ -2293,6 +2773,8 @@ async function informations() {
   }
 
   let submitted = false;
+  /** @type {string | null} */
+  let seededPlayerId = null;
 
   if (formElement) {
     formElement.onsubmit = function () {
@@ -2301,7 +2783,7 @@ async function informations() {
       }
 
       submitted = true;
-      submitForm(playerId);
+      submitForm(playerId ?? seededPlayerId);
 
       return false;
     };
@@ -0,0 +1,22 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file character_review.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file character_review.proto.
+ */
+export const file_character_review =
+  /*@__PURE__*/
+  fileDesc(
+    "ChZjaGFyYWN0ZXJfcmV2aWV3LnByb3RvEgl0aGVrZWVwZXIiSwoURXZlbnRDaGFyYWN0ZXJSZXZpZXcSEwoLY2hhcmFjdGVySWQYASABKAkSDQoFc3RhdGUYAiABKAkSDwoHY29tbWVudBgDIAEoCUIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
+  );
+
+/**
+ * Describes the message thekeeper.EventCharacterReview.
+ * Use `create(EventCharacterReviewSchema)` to create a new message.
+ */
+export const EventCharacterReviewSchema =
+  /*@__PURE__*/
+  messageDesc(file_character_review, 0);
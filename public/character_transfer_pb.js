@@ -0,0 +1,22 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file character_transfer.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file character_transfer.proto.
+ */
+export const file_character_transfer =
+  /*@__PURE__*/
+  fileDesc(
+    "ChhjaGFyYWN0ZXJfdHJhbnNmZXIucHJvdG8SCXRoZWtlZXBlciI/ChZFdmVudENoYXJhY3RlclRyYW5zZmVyEhMKC2NoYXJhY3RlcklkGAEgASgJEhAKCHBsYXllcklkGAIgASgJQipaKGdpdGh1Yi5jb20vZWJlbmF1bS90aGVrZWVwZXIvcHJvdG87cHJvdG9iBnByb3RvMw",
+  );
+
+/**
+ * Describes the message thekeeper.EventCharacterTransfer.
+ * Use `create(EventCharacterTransferSchema)` to create a new message.
+ */
+export const EventCharacterTransferSchema =
+  /*@__PURE__*/
+  messageDesc(file_character_transfer, 0);
@@ -0,0 +1,20 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file edition.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file edition.proto.
+ */
+export const file_edition =
+  /*@__PURE__*/
+  fileDesc(
+    "Cg1lZGl0aW9uLnByb3RvEgl0aGVrZWVwZXIiHAoMRXZlbnRFZGl0aW9uEgwKBG5hbWUYASABKAlCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+  );
+
+/**
+ * Describes the message thekeeper.EventEdition.
+ * Use `create(EventEditionSchema)` to create a new message.
+ */
+export const EventEditionSchema = /*@__PURE__*/ messageDesc(file_edition, 0);
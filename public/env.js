@@ -2,4 +2,7 @@ globalThis.env = {
   univers: "http://localhost:8080/univers.json",
   thekeeperURL: "http://localhost:8081",
   appURL: "http://localhost:8080",
+  // thekeeperHeadKey is the JWK the server logs when started with -head-key,
+  // the head of the log is not checked when it is unset.
+  // thekeeperHeadKey: { kty: "EC", crv: "P-256", x: "...", y: "..." },
 };
@@ -0,0 +1,20 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file erased.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file erased.proto.
+ */
+export const file_erased =
+  /*@__PURE__*/
+  fileDesc(
+    "CgxlcmFzZWQucHJvdG8SCXRoZWtlZXBlciJECgtFdmVudEVyYXNlZBIQCghwbGF5ZXJJZBgBIAEoCRIRCgllcmFzdXJlVHMYAiABKAMSEAoIcmVqZWN0ZWQYAyABKAhCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+  );
+
+/**
+ * Describes the message thekeeper.EventErased.
+ * Use `create(EventErasedSchema)` to create a new message.
+ */
+export const EventErasedSchema = /*@__PURE__*/ messageDesc(file_erased, 0);
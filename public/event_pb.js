@@ -9,6 +9,16 @@ import { file_seed_actor } from "./seed_actor_pb.js";
 import { file_player_person } from "./player_person_pb.js";
 import { file_player_character } from "./player_character_pb.js";
 import { file_player_character_orga_edit } from "./player_character_orga_edit_pb.js";
+import { file_edition } from "./edition_pb.js";
+import { file_univers } from "./univers_pb.js";
+import { file_rename_actor } from "./rename_actor_pb.js";
+import { file_actor_activation } from "./actor_activation_pb.js";
+import { file_player_erasure } from "./player_erasure_pb.js";
+import { file_erased } from "./erased_pb.js";
+import { file_character_review } from "./character_review_pb.js";
+import { file_character_transfer } from "./character_transfer_pb.js";
+import { file_group } from "./group_pb.js";
+import { file_relationship } from "./relationship_pb.js";
 
 /**
  * Describes the file event.proto.
@@ -16,7 +26,7 @@ import { file_player_character_orga_edit } from "./player_character_orga_edit_pb
 export const file_event =
   /*@__PURE__*/
   fileDesc(
-    "CgtldmVudC5wcm90bxIJdGhla2VlcGVyIv0CCgVFdmVudBIKCgJ0cxgBIAEoAxIwCgpQZXJtaXNzaW9uGAIgASgLMhoudGhla2VlcGVyLkV2ZW50UGVybWlzc2lvbkgAEjAKClNlZWRQbGF5ZXIYAyABKAsyGi50aGVrZWVwZXIuRXZlbnRTZWVkUGxheWVySAASLgoJU2VlZEFjdG9yGAQgASgLMhkudGhla2VlcGVyLkV2ZW50U2VlZEFjdG9ySAASNAoMUGxheWVyUGVyc29uGAUgASgLMhwudGhla2VlcGVyLkV2ZW50UGxheWVyUGVyc29uSAASOgoPUGxheWVyQ2hhcmFjdGVyGAYgASgLMh8udGhla2VlcGVyLkV2ZW50UGxheWVyQ2hhcmFjdGVySAASDwoFUmVzZXQYByABKAhIABJKChdQbGF5ZXJDaGFyYWN0ZXJPcmdhRWRpdBgIIAEoCzInLnRoZWtlZXBlci5FdmVudFBsYXllckNoYXJhY3Rlck9yZ2FFZGl0SABCBQoDbXNnIioKBkV2ZW50cxIgCgZldmVudHMYASADKAsyEC50aGVrZWVwZXIuRXZlbnRCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+    "CgtldmVudC5wcm90bxIJdGhla2VlcGVyIoEHCgVFdmVudBIKCgJ0cxgBIAEoAxIwCgpQZXJtaXNzaW9uGAIgASgLMhoudGhla2VlcGVyLkV2ZW50UGVybWlzc2lvbkgAEjAKClNlZWRQbGF5ZXIYAyABKAsyGi50aGVrZWVwZXIuRXZlbnRTZWVkUGxheWVySAASLgoJU2VlZEFjdG9yGAQgASgLMhkudGhla2VlcGVyLkV2ZW50U2VlZEFjdG9ySAASNAoMUGxheWVyUGVyc29uGAUgASgLMhwudGhla2VlcGVyLkV2ZW50UGxheWVyUGVyc29uSAASOgoPUGxheWVyQ2hhcmFjdGVyGAYgASgLMh8udGhla2VlcGVyLkV2ZW50UGxheWVyQ2hhcmFjdGVySAASDwoFUmVzZXQYByABKAhIABJKChdQbGF5ZXJDaGFyYWN0ZXJPcmdhRWRpdBgIIAEoCzInLnRoZWtlZXBlci5FdmVudFBsYXllckNoYXJhY3Rlck9yZ2FFZGl0SAASKgoHRWRpdGlvbhgJIAEoCzIXLnRoZWtlZXBlci5FdmVudEVkaXRpb25IABIqCgdVbml2ZXJzGAogASgLMhcudGhla2VlcGVyLkV2ZW50VW5pdmVyc0gAEjIKC1JlbmFtZUFjdG9yGAsgASgLMhsudGhla2VlcGVyLkV2ZW50UmVuYW1lQWN0b3JIABI6Cg9BY3RvckFjdGl2YXRpb24YDCABKAsyHy50aGVrZWVwZXIuRXZlbnRBY3RvckFjdGl2YXRpb25IABI2Cg1QbGF5ZXJFcmFzdXJlGA0gASgLMh0udGhla2VlcGVyLkV2ZW50UGxheWVyRXJhc3VyZUgAEigKBkVyYXNlZBgOIAEoCzIWLnRoZWtlZXBlci5FdmVudEVyYXNlZEgAEjoKD0NoYXJhY3RlclJldmlldxgPIAEoCzIfLnRoZWtlZXBlci5FdmVudENoYXJhY3RlclJldmlld0gAEj4KEUNoYXJhY3RlclRyYW5zZmVyGBAgASgLMiEudGhla2VlcGVyLkV2ZW50Q2hhcmFjdGVyVHJhbnNmZXJIABImCgVHcm91cBgRIAEoCzIVLnRoZWtlZXBlci5FdmVudEdyb3VwSAASNAoMUmVsYXRpb25zaGlwGBIgASgLMhwudGhla2VlcGVyLkV2ZW50UmVsYXRpb25zaGlwSABCBQoDbXNnIogBCgZFdmVudHMSIAoGZXZlbnRzGAEgAygLMhAudGhla2VlcGVyLkV2ZW50EhYKDmlkZW1wb3RlbmN5S2V5GAIgASgJEg8KB2FjdG9ySWQYAyABKAMSDgoGaGVhZFRzGAQgASgDEgwKBGhlYWQYBSABKAwSFQoNaGVhZFNpZ25hdHVyZRgGIAEoDEIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
     [
       file_permission,
       file_seed_player,
@@ -24,6 +34,16 @@ export const file_event =
       file_player_person,
       file_player_character,
       file_player_character_orga_edit,
+      file_edition,
+      file_univers,
+      file_rename_actor,
+      file_actor_activation,
+      file_player_erasure,
+      file_erased,
+      file_character_review,
+      file_character_transfer,
+      file_group,
+      file_relationship,
     ],
   );
 
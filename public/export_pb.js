@@ -0,0 +1,60 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file export.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+import { file_event } from "./event_pb.js";
+
+/**
+ * Describes the file export.proto.
+ */
+export const file_export =
+  /*@__PURE__*/
+  fileDesc(
+    "CgxleHBvcnQucHJvdG8SCXRoZWtlZXBlciIoCgtFeHBvcnRBY3RvchIKCgJpZBgBIAEoAxINCgVzcGFjZRgCIAEoCSJCCg9FeHBvcnRQdWJsaWNLZXkSCgoCaWQYASABKAMSEQoJcHVibGljS2V5GAIgASgMEhAKCGFjdG9ySWRzGAMgAygDIkEKDUV4cG9ydEF1dGhLZXkSCwoDa2V5GAEgASgJEg8KB2FjdG9ySWQYAiABKAMSEgoKcmVkZWVtZWRBdBgDIAEoAyJfCgtFeHBvcnRCYXRjaBIKCgJpZBgBIAEoAxITCgtwdWJsaWNLZXlJZBgCIAEoAxINCgV0b2tlbhgDIAEoCRIPCgdwYXlsb2FkGAQgASgMEg8KB2RpZ2VzdHMYBSABKAwipAEKC0V4cG9ydEV2ZW50EgoKAnRzGAEgASgDEhUKDXNvdXJjZUFjdG9ySWQYAiABKAMSDgoGc3RhdHVzGAMgASgJEg4KBnJlYXNvbhgEIAEoCRIfCgVldmVudBgFIAEoCzIQLnRoZWtlZXBlci5FdmVudBIPCgdiYXRjaElkGAYgASgDEhIKCmJhdGNoSW5kZXgYByABKAMSDAoEaGFzaBgIIAEoDCJJCg1FeHBvcnRSZXdyaXRlEgoKAnRzGAEgASgDEhAKCGRhdGFIYXNoGAIgASgMEgwKBGhlYWQYAyABKAwSDAoEaGFzaBgEIAEoDCKeAgoMRXhwb3J0UmVjb3JkEicKBUFjdG9yGAEgASgLMhYudGhla2VlcGVyLkV4cG9ydEFjdG9ySAASLwoJUHVibGljS2V5GAIgASgLMhoudGhla2VlcGVyLkV4cG9ydFB1YmxpY0tleUgAEisKB0F1dGhLZXkYAyABKAsyGC50aGVrZWVwZXIuRXhwb3J0QXV0aEtleUgAEicKBUV2ZW50GAQgASgLMhYudGhla2VlcGVyLkV4cG9ydEV2ZW50SAASJwoFQmF0Y2gYBSABKAsyFi50aGVrZWVwZXIuRXhwb3J0QmF0Y2hIABIrCgdSZXdyaXRlGAYgASgLMhgudGhla2VlcGVyLkV4cG9ydFJld3JpdGVIAEIICgZyZWNvcmRCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+    [
+      file_event,
+    ],
+  );
+
+/**
+ * Describes the message thekeeper.ExportActor.
+ * Use `create(ExportActorSchema)` to create a new message.
+ */
+export const ExportActorSchema = /*@__PURE__*/ messageDesc(file_export, 0);
+
+/**
+ * Describes the message thekeeper.ExportPublicKey.
+ * Use `create(ExportPublicKeySchema)` to create a new message.
+ */
+export const ExportPublicKeySchema = /*@__PURE__*/ messageDesc(file_export, 1);
+
+/**
+ * Describes the message thekeeper.ExportAuthKey.
+ * Use `create(ExportAuthKeySchema)` to create a new message.
+ */
+export const ExportAuthKeySchema = /*@__PURE__*/ messageDesc(file_export, 2);
+
+/**
+ * Describes the message thekeeper.ExportBatch.
+ * Use `create(ExportBatchSchema)` to create a new message.
+ */
+export const ExportBatchSchema = /*@__PURE__*/ messageDesc(file_export, 3);
+
+/**
+ * Describes the message thekeeper.ExportEvent.
+ * Use `create(ExportEventSchema)` to create a new message.
+ */
+export const ExportEventSchema = /*@__PURE__*/ messageDesc(file_export, 4);
+
+/**
+ * Describes the message thekeeper.ExportRewrite.
+ * Use `create(ExportRewriteSchema)` to create a new message.
+ */
+export const ExportRewriteSchema = /*@__PURE__*/ messageDesc(file_export, 5);
+
+/**
+ * Describes the message thekeeper.ExportRecord.
+ * Use `create(ExportRecordSchema)` to create a new message.
+ */
+export const ExportRecordSchema = /*@__PURE__*/ messageDesc(file_export, 6);
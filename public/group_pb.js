@@ -0,0 +1,20 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file group.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file group.proto.
+ */
+export const file_group =
+  /*@__PURE__*/
+  fileDesc(
+    "Cgtncm91cC5wcm90bxIJdGhla2VlcGVyIm0KCkV2ZW50R3JvdXASDwoHZ3JvdXBJZBgBIAEoCRIMCgRuYW1lGAIgASgJEhMKC2Rlc2NyaXB0aW9uGAMgASgJEhAKCGNhcGFjaXR5GAQgASgFEhkKEWxlYWRlckNoYXJhY3RlcklkGAUgASgJQipaKGdpdGh1Yi5jb20vZWJlbmF1bS90aGVrZWVwZXIvcHJvdG87cHJvdG9iBnByb3RvMw",
+  );
+
+/**
+ * Describes the message thekeeper.EventGroup.
+ * Use `create(EventGroupSchema)` to create a new message.
+ */
+export const EventGroupSchema = /*@__PURE__*/ messageDesc(file_group, 0);
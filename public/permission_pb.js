@@ -10,7 +10,7 @@ import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
 export const file_permission =
   /*@__PURE__*/
   fileDesc(
-    "ChBwZXJtaXNzaW9uLnByb3RvEgl0aGVrZWVwZXIiNwoPRXZlbnRQZXJtaXNzaW9uEhIKCnBlcm1pc3Npb24YASABKAkSEAoIYWN0b3JfaWQYAiABKANCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+    "ChBwZXJtaXNzaW9uLnByb3RvEgl0aGVrZWVwZXIiRwoPRXZlbnRQZXJtaXNzaW9uEhIKCnBlcm1pc3Npb24YASABKAkSEAoIYWN0b3JfaWQYAiABKAMSDgoGcmV2b2tlGAMgASgIQipaKGdpdGh1Yi5jb20vZWJlbmF1bS90aGVrZWVwZXIvcHJvdG87cHJvdG9iBnByb3RvMw",
   );
 
 /**
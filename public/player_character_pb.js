@@ -10,7 +10,7 @@ import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
 export const file_player_character =
   /*@__PURE__*/
   fileDesc(
-    "ChZwbGF5ZXJfY2hhcmFjdGVyLnByb3RvEgl0aGVrZWVwZXIiVgoPQ2hhcmFjdGVyaXN0aWNzEg0KBWNvcnBzGAEgASgFEhEKCWRleHRlcml0ZRgCIAEoBRIRCglpbmZsdWVuY2UYAyABKAUSDgoGc2F2b2lyGAQgASgFIswDChRFdmVudFBsYXllckNoYXJhY3RlchIQCghwbGF5ZXJJZBgBIAEoCRITCgtjaGFyYWN0ZXJJZBgCIAEoCRIMCgRuYW1lGAMgASgJEg0KBWdyb3VwGAQgASgJEgsKA3ZkdhgFIAEoCRIMCgRyYWNlGAYgASgJEjsKBnNraWxscxgHIAMoCzIrLnRoZWtlZXBlci5FdmVudFBsYXllckNoYXJhY3Rlci5Ta2lsbHNFbnRyeRIzCg9jaGFyYWN0ZXJpc3RpY3MYCCABKAsyGi50aGVrZWVwZXIuQ2hhcmFjdGVyaXN0aWNzEkEKCWludmVudG9yeRgJIAMoCzIuLnRoZWtlZXBlci5FdmVudFBsYXllckNoYXJhY3Rlci5JbnZlbnRvcnlFbnRyeRITCgt3b3JsZE9yaWdpbhgKIAEoCRIVCg13b3JsZEFwcHJvYWNoGAsgASgJEhMKC2Rlc2NyaXB0aW9uGAwgASgJGi0KC1NraWxsc0VudHJ5EgsKA2tleRgBIAEoCRINCgV2YWx1ZRgCIAEoBToCOAEaMAoOSW52ZW50b3J5RW50cnkSCwoDa2V5GAEgASgJEg0KBXZhbHVlGAIgASgFOgI4AUIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
+    "ChZwbGF5ZXJfY2hhcmFjdGVyLnByb3RvEgl0aGVrZWVwZXIiVgoPQ2hhcmFjdGVyaXN0aWNzEg0KBWNvcnBzGAEgASgFEhEKCWRleHRlcml0ZRgCIAEoBRIRCglpbmZsdWVuY2UYAyABKAUSDgoGc2F2b2lyGAQgASgFIosEChRFdmVudFBsYXllckNoYXJhY3RlchIQCghwbGF5ZXJJZBgBIAEoCRITCgtjaGFyYWN0ZXJJZBgCIAEoCRIMCgRuYW1lGAMgASgJEg0KBWdyb3VwGAQgASgJEgsKA3ZkdhgFIAEoCRIMCgRyYWNlGAYgASgJEjsKBnNraWxscxgHIAMoCzIrLnRoZWtlZXBlci5FdmVudFBsYXllckNoYXJhY3Rlci5Ta2lsbHNFbnRyeRIzCg9jaGFyYWN0ZXJpc3RpY3MYCCABKAsyGi50aGVrZWVwZXIuQ2hhcmFjdGVyaXN0aWNzEkEKCWludmVudG9yeRgJIAMoCzIuLnRoZWtlZXBlci5FdmVudFBsYXllckNoYXJhY3Rlci5JbnZlbnRvcnlFbnRyeRITCgt3b3JsZE9yaWdpbhgKIAEoCRIVCg13b3JsZEFwcHJvYWNoGAsgASgJEhMKC2Rlc2NyaXB0aW9uGAwgASgJEhcKD2V4cGVjdGVkVmVyc2lvbhgNIAEoAxITCgtieXBhc3NSdWxlcxgOIAEoCBIPCgdncm91cElkGA8gASgJGi0KC1NraWxsc0VudHJ5EgsKA2tleRgBIAEoCRINCgV2YWx1ZRgCIAEoBToCOAEaMAoOSW52ZW50b3J5RW50cnkSCwoDa2V5GAEgASgJEg0KBXZhbHVlGAIgASgFOgI4AUIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
   );
 
 /**
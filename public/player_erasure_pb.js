@@ -0,0 +1,22 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file player_erasure.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file player_erasure.proto.
+ */
+export const file_player_erasure =
+  /*@__PURE__*/
+  fileDesc(
+    "ChRwbGF5ZXJfZXJhc3VyZS5wcm90bxIJdGhla2VlcGVyIiYKEkV2ZW50UGxheWVyRXJhc3VyZRIQCghwbGF5ZXJJZBgBIAEoCUIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
+  );
+
+/**
+ * Describes the message thekeeper.EventPlayerErasure.
+ * Use `create(EventPlayerErasureSchema)` to create a new message.
+ */
+export const EventPlayerErasureSchema =
+  /*@__PURE__*/
+  messageDesc(file_player_erasure, 0);
@@ -10,7 +10,7 @@ import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
 export const file_player_person =
   /*@__PURE__*/
   fileDesc(
-    "ChNwbGF5ZXJfcGVyc29uLnByb3RvEgl0aGVrZWVwZXIisgMKEUV2ZW50UGxheWVyUGVyc29uEhAKCHBsYXllcklkGAEgASgJEg8KB3N1cm5hbWUYAiABKAkSCwoDYWdlGAMgASgJEhQKDGNpdHlPZk9yaWdpbhgEIAEoCRIPCgdjb250YWN0GAUgASgJEhgKEGVtZXJnZW5jeUNvbnRhY3QYBiABKAkSDgoGaGVhbHRoGAcgASgJEh0KFWFkZGl0aW9uYWxJbmZvcm1hdGlvbhgIIAEoCRIYChBwZW9wbGVUb1BsYXlXaXRoGAkgASgJEg4KBnNraWxscxgKIAEoCRIcChR1c2VFeGlzdGluZ0NoYXJhY3RlchgLIAEoCBIaChJhcHByb3ZlZENvbmRpdGlvbnMYDCABKAgSJQodZXhpc3RpbmdDaGFyYWN0ZXJBY2hpZXZlbWVudHMYDSABKAkSEQoJZ2FtZVN0eWxlGA4gASgJEhUKDWdhbWVTdHlsZVRhZ3MYDyADKAkSGAoQc2l0dWF0aW9uVG9Bdm9pZBgQIAEoCRIXCg9pbnNjcmlwdGlvblR5cGUYESABKAkSFQoNcGljdHVyZVJpZ2h0cxgSIAEoCEIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
+    "ChNwbGF5ZXJfcGVyc29uLnByb3RvEgl0aGVrZWVwZXIi5AMKEUV2ZW50UGxheWVyUGVyc29uEhAKCHBsYXllcklkGAEgASgJEg8KB3N1cm5hbWUYAiABKAkSCwoDYWdlGAMgASgJEhQKDGNpdHlPZk9yaWdpbhgEIAEoCRIPCgdjb250YWN0GAUgASgJEhgKEGVtZXJnZW5jeUNvbnRhY3QYBiABKAkSDgoGaGVhbHRoGAcgASgJEh0KFWFkZGl0aW9uYWxJbmZvcm1hdGlvbhgIIAEoCRIYChBwZW9wbGVUb1BsYXlXaXRoGAkgASgJEg4KBnNraWxscxgKIAEoCRIcChR1c2VFeGlzdGluZ0NoYXJhY3RlchgLIAEoCBIaChJhcHByb3ZlZENvbmRpdGlvbnMYDCABKAgSJQodZXhpc3RpbmdDaGFyYWN0ZXJBY2hpZXZlbWVudHMYDSABKAkSEQoJZ2FtZVN0eWxlGA4gASgJEhUKDWdhbWVTdHlsZVRhZ3MYDyADKAkSGAoQc2l0dWF0aW9uVG9Bdm9pZBgQIAEoCRIXCg9pbnNjcmlwdGlvblR5cGUYESABKAkSFQoNcGljdHVyZVJpZ2h0cxgSIAEoCBIXCg9leHBlY3RlZFZlcnNpb24YEyABKAMSFwoPbWVkaWNhbFJlZGFjdGVkGBQgASgIQipaKGdpdGh1Yi5jb20vZWJlbmF1bS90aGVrZWVwZXIvcHJvdG87cHJvdG9iBnByb3RvMw",
   );
 
 /**
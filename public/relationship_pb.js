@@ -0,0 +1,22 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file relationship.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file relationship.proto.
+ */
+export const file_relationship =
+  /*@__PURE__*/
+  fileDesc(
+    "ChJyZWxhdGlvbnNoaXAucHJvdG8SCXRoZWtlZXBlciK6AQoRRXZlbnRSZWxhdGlvbnNoaXASFgoOcmVsYXRpb25zaGlwSWQYASABKAkSFwoPZnJvbUNoYXJhY3RlcklkGAIgASgJEhUKDXRvQ2hhcmFjdGVySWQYAyABKAkSDAoEdHlwZRgEIAEoCRITCgtkZXNjcmlwdGlvbhgFIAEoCRIVCg12aXNpYmxlVG9Gcm9tGAYgASgIEhMKC3Zpc2libGVUb1RvGAcgASgIEg4KBnJlbW92ZRgIIAEoCEIqWihnaXRodWIuY29tL2ViZW5hdW0vdGhla2VlcGVyL3Byb3RvO3Byb3RvYgZwcm90bzM",
+  );
+
+/**
+ * Describes the message thekeeper.EventRelationship.
+ * Use `create(EventRelationshipSchema)` to create a new message.
+ */
+export const EventRelationshipSchema =
+  /*@__PURE__*/
+  messageDesc(file_relationship, 0);
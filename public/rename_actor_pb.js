@@ -0,0 +1,22 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file rename_actor.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file rename_actor.proto.
+ */
+export const file_rename_actor =
+  /*@__PURE__*/
+  fileDesc(
+    "ChJyZW5hbWVfYWN0b3IucHJvdG8SCXRoZWtlZXBlciI1ChBFdmVudFJlbmFtZUFjdG9yEg4KBmhhbmRsZRgBIAEoCRIRCgluZXdIYW5kbGUYAiABKAlCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+  );
+
+/**
+ * Describes the message thekeeper.EventRenameActor.
+ * Use `create(EventRenameActorSchema)` to create a new message.
+ */
+export const EventRenameActorSchema =
+  /*@__PURE__*/
+  messageDesc(file_rename_actor, 0);
@@ -0,0 +1,20 @@
+// @generated by protoc-gen-es v2.2.3 with parameter "import_extension=js,target=js"
+// @generated from file univers.proto (package thekeeper, syntax proto3)
+/* eslint-disable */
+
+import { fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv1";
+
+/**
+ * Describes the file univers.proto.
+ */
+export const file_univers =
+  /*@__PURE__*/
+  fileDesc(
+    "Cg11bml2ZXJzLnByb3RvEgl0aGVrZWVwZXIiIgoMRXZlbnRVbml2ZXJzEhIKCmRlZmluaXRpb24YASABKAxCKlooZ2l0aHViLmNvbS9lYmVuYXVtL3RoZWtlZXBlci9wcm90bztwcm90b2IGcHJvdG8z",
+  );
+
+/**
+ * Describes the message thekeeper.EventUnivers.
+ * Use `create(EventUniversSchema)` to create a new message.
+ */
+export const EventUniversSchema = /*@__PURE__*/ messageDesc(file_univers, 0);
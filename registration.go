@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldRule constrains a field of a registration, named after its JSON name,
+// which is also the name of its input in public/informations.html.
+type FieldRule struct {
+	Field string
+	// Required fields must be non-empty, or true for a bool.
+	Required bool
+	// Allowed, when set, lists the values a non-empty field can take.
+	Allowed []string
+	// MaxLength, when set, caps the number of characters of a field or of
+	// each of its values.
+	MaxLength int
+}
+
+// RegistrationRules are the rules a submitted EventPlayerPerson must follow.
+// The registrations accepted before they change stay accepted.
+var RegistrationRules = []FieldRule{
+	{Field: "surname", Required: true, MaxLength: 200},
+	{Field: "contact", Required: true, MaxLength: 200},
+	{Field: "emergencyContact", Required: true, MaxLength: 200},
+	{Field: "approvedConditions", Required: true},
+	{Field: "inscriptionType", Required: true, Allowed: []string{"pj", "pnj"}},
+	{Field: "age", Allowed: []string{"-12ans", "-18ans", "-99ans"}},
+	{Field: "cityOfOrigin", MaxLength: 200},
+	{Field: "health", MaxLength: 5000},
+	{Field: "additionalInformation", MaxLength: 5000},
+	{Field: "peopleToPlayWith", MaxLength: 1000},
+	{Field: "skills", MaxLength: 5000},
+	{Field: "existingCharacterAchievements", MaxLength: 10000},
+	{Field: "gameStyleTags", MaxLength: 100},
+	{Field: "situationToAvoid", MaxLength: 5000},
+}
+
+// FieldErrors maps the fields of an event to the rule they break.
+type FieldErrors map[string]string
+
+func (f FieldErrors) Error() string {
+	fields := make([]string, 0, len(f))
+
+	for field := range f {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for i, field := range fields {
+		fields[i] = field + ": " + f[field]
+	}
+
+	return "invalid fields: " + strings.Join(fields, ", ")
+}
+
+func (r FieldRule) check(value protoreflect.Value, kind protoreflect.Kind, list bool) string {
+	if kind == protoreflect.BoolKind {
+		if r.Required && !value.Bool() {
+			return "required"
+		}
+
+		return ""
+	}
+
+	values := []string{}
+
+	if list {
+		for i := range value.List().Len() {
+			values = append(values, value.List().Get(i).String())
+		}
+	} else if value.String() != "" {
+		values = append(values, value.String())
+	}
+
+	if r.Required && len(values) == 0 {
+		return "required"
+	}
+
+	for _, v := range values {
+		if r.Allowed != nil && !slices.Contains(r.Allowed, v) {
+			return fmt.Sprintf("must be one of %s", strings.Join(r.Allowed, ", "))
+		}
+
+		if r.MaxLength > 0 && utf8.RuneCountInString(v) > r.MaxLength {
+			return fmt.Sprintf("longer than %d characters", r.MaxLength)
+		}
+	}
+
+	return ""
+}
+
+// CheckRegistrationRules returns an error when one of rules names a field
+// EventPlayerPerson does not have.
+func CheckRegistrationRules(rules []FieldRule) error {
+	fields := (&proto.EventPlayerPerson{}).ProtoReflect().Descriptor().Fields()
+
+	for _, rule := range rules {
+		if fields.ByJSONName(rule.Field) == nil {
+			return fmt.Errorf("registration rule for unknown field %q", rule.Field)
+		}
+	}
+
+	return nil
+}
+
+// CheckRegistration returns the FieldErrors of person against
+// RegistrationRules, nil when it follows them all.
+func CheckRegistration(person *proto.EventPlayerPerson) error {
+	message := person.ProtoReflect()
+	fields := message.Descriptor().Fields()
+
+	errs := FieldErrors{}
+
+	for _, rule := range RegistrationRules {
+		field := fields.ByJSONName(rule.Field)
+		if field == nil {
+			return fmt.Errorf("registration rule for unknown field %q", rule.Field)
+		}
+
+		if reason := rule.check(message.Get(field), field.Kind(), field.IsList()); reason != "" {
+			errs[rule.Field] = reason
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func playerPerson(playerID string) *proto.EventPlayerPerson {
+	return &proto.EventPlayerPerson{
+		PlayerId:           playerID,
+		Surname:            "Jean",
+		Contact:            "jean@example.com",
+		EmergencyContact:   "0600000000",
+		ApprovedConditions: true,
+		InscriptionType:    "pj",
+	}
+}
+
+func TestCheckRegistration(t *testing.T) {
+	for name, test := range map[string]struct {
+		edit func(person *proto.EventPlayerPerson)
+		want FieldErrors
+	}{
+		"valid": {func(person *proto.EventPlayerPerson) {}, nil},
+		"missing fields": {func(person *proto.EventPlayerPerson) {
+			person.Contact = ""
+			person.EmergencyContact = ""
+			person.ApprovedConditions = false
+		}, FieldErrors{
+			"contact":            "required",
+			"emergencyContact":   "required",
+			"approvedConditions": "required",
+		}},
+		"unknown values": {func(person *proto.EventPlayerPerson) {
+			person.InscriptionType = "orga"
+			person.Age = "42"
+		}, FieldErrors{
+			"inscriptionType": "must be one of pj, pnj",
+			"age":             "must be one of -12ans, -18ans, -99ans",
+		}},
+		"too long": {func(person *proto.EventPlayerPerson) {
+			person.Surname = strings.Repeat("é", 201)
+			person.GameStyleTags = []string{"Rôles légers", strings.Repeat("a", 101)}
+		}, FieldErrors{
+			"surname":       "longer than 200 characters",
+			"gameStyleTags": "longer than 100 characters",
+		}},
+	} {
+		person := playerPerson("player:coffee-art")
+		test.edit(person)
+
+		var got FieldErrors
+
+		if err := CheckRegistration(person); err != nil {
+			got = err.(FieldErrors)
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: field errors (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestInsertAndCheckEventsReportsFieldErrors(t *testing.T) {
+	store := newTestStore(t)
+
+	person := playerPerson("player:coffee-art")
+	person.InscriptionType = ""
+
+	results, err := InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		{Msg: &proto.Event_PlayerPerson{PlayerPerson: person}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rejected := results[2]
+	if rejected.Status != EventRecordStatusRejected {
+		t.Fatalf("registration without inscription type: %+v", rejected)
+	}
+
+	if diff := cmp.Diff(FieldErrors{"inscriptionType": "required"}, rejected.Fields); diff != "" {
+		t.Errorf("field errors (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckRegistrationRules(t *testing.T) {
+	if err := CheckRegistrationRules(RegistrationRules); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckRegistrationRules([]FieldRule{{Field: "shoeSize", Required: true}}); err == nil {
+		t.Error("rule for an unknown field accepted")
+	}
+}
+
+func TestRegistrationRulesApplyToSubmissions(t *testing.T) {
+	store := newTestStore(t)
+
+	results, err := InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+		{Msg: &proto.Event_PlayerPerson{PlayerPerson: playerPerson("player:coffee-art")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := results[2].Ts
+
+	rules := RegistrationRules
+	t.Cleanup(func() { RegistrationRules = rules })
+
+	RegistrationRules = append(slices.Clone(rules), FieldRule{Field: "cityOfOrigin", Required: true})
+
+	err = VerifyReplay(store)
+	if err != nil {
+		t.Fatalf("registration accepted under the previous rules: %v", err)
+	}
+
+	edit := playerPerson("player:coffee-art")
+	edit.ExpectedVersion = accepted
+
+	results, err = InsertAndCheckEvents(store, NewValidation(), -1, 2, nil, []*proto.Event{{Msg: &proto.Event_PlayerPerson{PlayerPerson: edit}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(FieldErrors{"cityOfOrigin": "required"}, results[0].Fields); diff != "" {
+		t.Errorf("new submission, field errors (-want +got):\n%s", diff)
+	}
+
+	if status := storedStatuses(t, store)[accepted]; status != EventRecordStatusAccepted {
+		t.Errorf("previous registration has status %v", status)
+	}
+}
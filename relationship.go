@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+// Relationships maps the relationships to the characters they link.
+type Relationships map[string]struct {
+	FromCharacterID string
+	ToCharacterID   string
+}
+
+// Process checks a relationship between characters of characterIDs and
+// applies it.
+func (r Relationships) Process(characterIDs map[string]struct{ PlayerID string }, event *proto.EventRelationship) error {
+	if event.RelationshipId == "" {
+		return fmt.Errorf("invalid relationship id")
+	}
+
+	existing, exists := r[event.RelationshipId]
+
+	if event.Remove {
+		if !exists {
+			return fmt.Errorf("relationship does not exist")
+		}
+
+		delete(r, event.RelationshipId)
+
+		return nil
+	}
+
+	for _, characterID := range []string{event.FromCharacterId, event.ToCharacterId} {
+		if _, exists := characterIDs[characterID]; !exists {
+			return fmt.Errorf("character %q does not exist", characterID)
+		}
+	}
+
+	if event.FromCharacterId == event.ToCharacterId {
+		return fmt.Errorf("a character cannot be in a relationship with itself")
+	}
+
+	if exists && (existing.FromCharacterID != event.FromCharacterId || existing.ToCharacterID != event.ToCharacterId) {
+		return fmt.Errorf("relationship %q links other characters", event.RelationshipId)
+	}
+
+	if event.Type == "" {
+		return FieldErrors{"type": "required"}
+	}
+
+	r[event.RelationshipId] = struct {
+		FromCharacterID string
+		ToCharacterID   string
+	}{event.FromCharacterId, event.ToCharacterId}
+
+	return nil
+}
+
+// knowsRelationship tells whether the player of one of characterIDs is
+// allowed to know about a relationship.
+func knowsRelationship(characterIDs map[string]struct{}, relationship *proto.EventRelationship) bool {
+	_, from := characterIDs[relationship.FromCharacterId]
+	_, to := characterIDs[relationship.ToCharacterId]
+
+	return (from && relationship.VisibleToFrom) || (to && relationship.VisibleToTo)
+}
+
+// playerRelationship returns the part of a relationship event a player sees,
+// without the visibility of each side.
+func playerRelationship(event *proto.Event) *proto.Event {
+	relationship := event.GetRelationship()
+
+	return &proto.Event{
+		Ts: event.Ts,
+		Msg: &proto.Event_Relationship{
+			Relationship: &proto.EventRelationship{
+				RelationshipId:  relationship.RelationshipId,
+				FromCharacterId: relationship.FromCharacterId,
+				ToCharacterId:   relationship.ToCharacterId,
+				Type:            relationship.Type,
+				Description:     relationship.Description,
+				Remove:          relationship.Remove,
+			},
+		},
+	}
+}
+
+// dropRelationship removes the events of a relationship hidden from a player
+// from the events of a projection. The events are copied, see erasePersons.
+func dropRelationship(events []*proto.Event, id string) []*proto.Event {
+	kept := make([]*proto.Event, 0, len(events))
+
+	for _, event := range events {
+		if event.GetRelationship().GetRelationshipId() != id {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept
+}
+
+// refreshRelationships brings the relationships of the projection in line
+// with the characters of the player after the event at ts: the relationships
+// the player gets to know are merged in, in place of the removal of a
+// previous one, the ones it no longer knows about are dropped and replaced by
+// a removal.
+func (s *SpacePlayer) refreshRelationships(ts int64, changedID string) {
+	for id, event := range s.Relationships {
+		_, known := s.KnownRelationships[id]
+
+		switch knows := knowsRelationship(s.CharacterIDs, event.GetRelationship()); {
+		case knows && !known:
+			s.Events = mergeEvents(dropRelationship(s.Events, id), []*proto.Event{playerRelationship(event)})
+			s.KnownRelationships[id] = struct{}{}
+		case knows && id == changedID:
+			s.Events = append(s.Events, playerRelationship(event))
+		case !knows && known:
+			s.forgetRelationship(ts, id)
+		}
+	}
+
+	for id := range s.KnownRelationships {
+		if _, exists := s.Relationships[id]; !exists {
+			s.forgetRelationship(ts, id)
+		}
+	}
+}
+
+func (s *SpacePlayer) forgetRelationship(ts int64, id string) {
+	removal := &proto.Event{
+		Ts: ts,
+		Msg: &proto.Event_Relationship{
+			Relationship: &proto.EventRelationship{RelationshipId: id, Remove: true},
+		},
+	}
+
+	s.Events = append(dropRelationship(s.Events, id), removal)
+	delete(s.KnownRelationships, id)
+}
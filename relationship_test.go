@@ -0,0 +1,225 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func relationship(id, from, to, kind string, visibleToFrom, visibleToTo bool) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_Relationship{Relationship: &proto.EventRelationship{
+		RelationshipId:  id,
+		FromCharacterId: from,
+		ToCharacterId:   to,
+		Type:            kind,
+		VisibleToFrom:   visibleToFrom,
+		VisibleToTo:     visibleToTo,
+	}}}
+}
+
+func TestRelationships(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+	projections := NewProjections()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	// known returns the relationships the player of actorID knows about once
+	// its events are applied, with fresh and incremental projections.
+	known := func(actorID int64) map[string]*proto.EventRelationship {
+		t.Helper()
+
+		var relationships map[string]*proto.EventRelationship
+
+		for _, projections := range []*Projections{projections, NewProjections()} {
+			events, err := FetchEvents(store, projections, actorID, ActorSpacePlayer, "", -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]*proto.EventRelationship{}
+
+			for _, event := range events {
+				relationship := event.GetRelationship()
+				if relationship == nil {
+					continue
+				}
+
+				if relationship.VisibleToFrom || relationship.VisibleToTo {
+					t.Errorf("actor %d gets the visibility of %q", actorID, relationship.RelationshipId)
+				}
+
+				if relationship.Remove {
+					delete(got, relationship.RelationshipId)
+				} else {
+					got[relationship.RelationshipId] = relationship
+				}
+			}
+
+			if relationships != nil {
+				if diff := cmp.Diff(relationships, got, protocmp.Transform()); diff != "" {
+					t.Errorf("actor %d: incremental and fresh projections differ (-incremental +fresh):\n%s", actorID, diff)
+				}
+			}
+
+			relationships = got
+		}
+
+		return relationships
+	}
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(2, playerCharacter("player:coffee-art", "character:1"))
+	insert(3, seedActor("friend"))
+	insert(3, seedPlayer("friend", "player:friend"))
+	insert(3, playerCharacter("player:friend", "character:2"))
+	insert(0, permission(1, PermissionScenarist, false))
+
+	known(2)
+	known(3)
+
+	for i, test := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+		accepted      bool
+	}{
+		{2, relationship("r1", "character:1", "character:2", "siblings", true, true), false},
+		{1, relationship("r1", "character:1", "character:3", "siblings", true, true), false},
+		{1, relationship("r1", "character:1", "character:1", "siblings", true, true), false},
+		{1, relationship("r1", "character:1", "character:2", "", true, true), false},
+		{1, &proto.Event{Msg: &proto.Event_Relationship{Relationship: &proto.EventRelationship{RelationshipId: "r1", Remove: true}}}, false},
+		{1, relationship("r1", "character:1", "character:2", "siblings", true, false), true},
+		{1, relationship("r1", "character:2", "character:1", "siblings", true, false), false},
+	} {
+		result := insert(test.sourceActorID, test.event)
+		if (result.Status == EventRecordStatusAccepted) != test.accepted {
+			t.Errorf("#%d: accepted %v, got %+v", i, test.accepted, result)
+		}
+	}
+
+	if got := known(2); got["r1"].GetType() != "siblings" {
+		t.Errorf("from side knows %v", got)
+	}
+
+	if got := known(3); len(got) != 0 {
+		t.Errorf("hidden side knows %v", got)
+	}
+
+	insert(1, relationship("r1", "character:1", "character:2", "rivals", false, true))
+
+	if got := known(2); len(got) != 0 {
+		t.Errorf("from side still knows %v once hidden", got)
+	}
+
+	if got := known(3); got["r1"].GetType() != "rivals" {
+		t.Errorf("to side knows %v once shown", got)
+	}
+
+	// Relationships follow the characters to their new player.
+	insert(1, relationship("r2", "character:1", "character:2", "debt", false, true))
+	insert(1, transfer("character:2", "player:coffee-art"))
+
+	if got := known(2); len(got) != 2 {
+		t.Errorf("new player of character:2 knows %v", got)
+	}
+
+	if got := known(3); len(got) != 0 {
+		t.Errorf("previous player of character:2 knows %v", got)
+	}
+
+	insert(1, &proto.Event{Msg: &proto.Event_Relationship{Relationship: &proto.EventRelationship{RelationshipId: "r1", Remove: true}}})
+
+	if got := known(2); len(got) != 1 || got["r2"] == nil {
+		t.Errorf("after the removal of r1, knows %v", got)
+	}
+}
+
+func TestRelationshipsAndTransfersScopedToEdition(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(2, playerCharacter("player:coffee-art", "character:1"))
+	insert(3, seedActor("friend"))
+	insert(3, seedPlayer("friend", "player:friend"))
+	insert(3, playerCharacter("player:friend", "character:2"))
+	insert(0, permission(1, PermissionOrga, false))
+
+	for _, event := range []*proto.Event{
+		relationship("relationship:1", "character:1", "character:2", "rivals", true, true),
+		transfer("character:1", "player:friend"),
+	} {
+		if result := insert(1, event); result.Status != EventRecordStatusAccepted {
+			t.Fatalf("%v: %+v", event, result)
+		}
+	}
+
+	insert(0, &proto.Event{Msg: &proto.Event_Edition{Edition: &proto.EventEdition{Name: "2027"}}})
+
+	scoped := func(actorID int64, space ActorSpace, edition string) (relationships, transfers int) {
+		t.Helper()
+
+		events, err := FetchEvents(store, NewProjections(), actorID, space, edition, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, event := range events {
+			switch event.Msg.(type) {
+			case *proto.Event_Relationship:
+				relationships++
+			case *proto.Event_CharacterTransfer:
+				transfers++
+			}
+		}
+
+		return relationships, transfers
+	}
+
+	for _, test := range []struct {
+		actorID int64
+		space   ActorSpace
+	}{
+		{1, ActorSpaceOrga},
+		{3, ActorSpacePlayer},
+	} {
+		if relationships, transfers := scoped(test.actorID, test.space, EditionInitial); relationships != 1 || transfers != 1 {
+			t.Errorf("actor %d gets %d relationships and %d transfers in the initial edition", test.actorID, relationships, transfers)
+		}
+
+		if relationships, transfers := scoped(test.actorID, test.space, ""); relationships != 0 || transfers != 0 {
+			t.Errorf("actor %d gets %d relationships and %d transfers of the previous edition", test.actorID, relationships, transfers)
+		}
+	}
+
+	// The relationship is gone from the new edition for the validation too.
+	removal := &proto.Event{Msg: &proto.Event_Relationship{Relationship: &proto.EventRelationship{RelationshipId: "relationship:1", Remove: true}}}
+
+	if result := insert(1, removal); result.Status != EventRecordStatusRejected {
+		t.Errorf("removal of a relationship of the previous edition: %+v", result)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+const (
+	ReviewDraft            = "draft"
+	ReviewSubmitted        = "submitted"
+	ReviewChangesRequested = "changes-requested"
+	ReviewApproved         = "approved"
+)
+
+type reviewTransition struct {
+	From string
+	To   string
+}
+
+// ReviewTransitions lists the moves of the approval workflow and whether they
+// are reserved to the actors able to CapabilityEditCharacters. The other
+// moves are open to the player of the character as well.
+var ReviewTransitions = map[reviewTransition]bool{
+	{ReviewDraft, ReviewSubmitted}:            false,
+	{ReviewSubmitted, ReviewDraft}:            false,
+	{ReviewChangesRequested, ReviewSubmitted}: false,
+	{ReviewSubmitted, ReviewChangesRequested}: true,
+	{ReviewSubmitted, ReviewApproved}:         true,
+	{ReviewApproved, ReviewChangesRequested}:  true,
+}
+
+// Reviews holds the review state of the characters, a character without one
+// is a draft.
+type Reviews map[string]string
+
+func (r Reviews) State(characterID string) string {
+	if state, exists := r[characterID]; exists {
+		return state
+	}
+
+	return ReviewDraft
+}
+
+// Process moves the character of event to its new state. isPlayer tells
+// whether the source of event is the player of the character, canEdit whether
+// it is able to CapabilityEditCharacters.
+func (r Reviews) Process(isPlayer, canEdit bool, event *proto.EventCharacterReview) error {
+	from := r.State(event.CharacterId)
+
+	orgaOnly, allowed := ReviewTransitions[reviewTransition{from, event.State}]
+	if !allowed {
+		return fmt.Errorf("a character cannot go from %s to %q", from, event.State)
+	}
+
+	if !canEdit && (orgaOnly || !isPlayer) {
+		return fmt.Errorf("not authorized: missing permission")
+	}
+
+	if event.State == ReviewChangesRequested && event.Comment == "" {
+		return FieldErrors{"comment": "required"}
+	}
+
+	r[event.CharacterId] = event.State
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+)
+
+func review(characterID, state, comment string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_CharacterReview{CharacterReview: &proto.EventCharacterReview{CharacterId: characterID, State: state, Comment: comment}}}
+}
+
+func TestCharacterReview(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(2, playerCharacter("player:coffee-art", "character:1"))
+	insert(0, permission(1, PermissionOrga, false))
+
+	for i, test := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+		accepted      bool
+	}{
+		{2, review("character:1", ReviewApproved, ""), false},
+		{1, review("character:1", ReviewChangesRequested, "Trop de compétences"), false},
+		{3, review("character:1", ReviewSubmitted, ""), false},
+		{2, review("character:2", ReviewSubmitted, ""), false},
+		{2, review("character:1", ReviewSubmitted, ""), true},
+		{1, review("character:1", ReviewChangesRequested, ""), false},
+		{1, review("character:1", ReviewChangesRequested, "Trop de compétences"), true},
+		{2, review("character:1", ReviewSubmitted, ""), true},
+		{2, review("character:1", ReviewApproved, ""), false},
+		{1, review("character:1", ReviewApproved, ""), true},
+		{2, playerCharacter("player:coffee-art", "character:1"), false},
+		{1, playerCharacter("player:coffee-art", "character:1"), true},
+	} {
+		result := insert(test.sourceActorID, test.event)
+		if (result.Status == EventRecordStatusAccepted) != test.accepted {
+			t.Errorf("#%d: accepted %v, got %+v", i, test.accepted, result)
+		}
+	}
+
+	for _, space := range []struct {
+		actorID int64
+		space   ActorSpace
+	}{{1, ActorSpaceOrga}, {2, ActorSpacePlayer}} {
+		events, err := FetchEvents(store, NewProjections(), space.actorID, space.space, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var states []string
+
+		for _, event := range events {
+			if review := event.GetCharacterReview(); review != nil {
+				states = append(states, review.State)
+			}
+		}
+
+		want := []string{ReviewSubmitted, ReviewChangesRequested, ReviewSubmitted, ReviewApproved}
+		if diff := cmp.Diff(want, states); diff != "" {
+			t.Errorf("actor %d: reviews (-want +got):\n%s", space.actorID, diff)
+		}
+	}
+
+	insert(0, &proto.Event{Msg: &proto.Event_Edition{Edition: &proto.EventEdition{Name: "2027"}}})
+
+	if result := insert(2, playerCharacter("player:coffee-art", "character:1")); result.Status != EventRecordStatusAccepted {
+		t.Errorf("edit of a character approved in a previous edition: %+v", result)
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/ebenaum/thekeeper/proto"
+	protolib "google.golang.org/protobuf/proto"
 )
 
 type Actor struct {
@@ -20,6 +23,38 @@ type SpaceValidation struct {
 	CharacterIDs map[string]struct {
 		PlayerID string
 	}
+	Editions map[string]struct{}
+	// PersonVersions and CharacterVersions hold the ts of the last accepted
+	// EventPlayerPerson of each player and EventPlayerCharacter of each
+	// character in the current edition.
+	PersonVersions    map[string]int64
+	CharacterVersions map[string]int64
+	// Univers holds the character creation rules, nil until a univers event
+	// is accepted.
+	Univers *Univers
+	// Deactivated holds the actors whose events are rejected.
+	Deactivated map[int64]struct{}
+	// Medical holds the medical fields of the last registration of each
+	// player, for the edits based on a redacted one.
+	Medical map[string]MedicalFields
+	// Reviews holds the approval state of the characters in the current
+	// edition.
+	Reviews       Reviews
+	Groups        Groups
+	Relationships Relationships
+}
+
+// ErrConflict is returned for an edit based on a stale version of a record.
+var ErrConflict = errors.New("conflict: someone else edited this")
+
+// checkVersion returns ErrConflict when expected is set and differs from the
+// current version of a record.
+func checkVersion(versions map[string]int64, id string, expected int64) error {
+	if expected != 0 && versions[id] != expected {
+		return fmt.Errorf("%w: %q is at version %d, not %d", ErrConflict, id, versions[id], expected)
+	}
+
+	return nil
 }
 
 func NewSpaceValidation() SpaceValidation {
@@ -37,6 +72,16 @@ func NewSpaceValidation() SpaceValidation {
 		},
 		PlayersIDs:   map[string]struct{ ActorID int64 }{},
 		CharacterIDs: map[string]struct{ PlayerID string }{},
+		Editions:     map[string]struct{}{},
+		Deactivated:  map[int64]struct{}{},
+		Medical:      map[string]MedicalFields{},
+		Reviews:      Reviews{},
+		Groups:       NewGroups(),
+
+		Relationships: Relationships{},
+
+		PersonVersions:    map[string]int64{},
+		CharacterVersions: map[string]int64{},
 	}
 }
 
@@ -64,15 +109,93 @@ func (h Handles) Process(sourceActorID int64, event *proto.EventSeedActor) error
 	return nil
 }
 
+// Rename moves actorID to handle, its previous handle is freed.
+func (h Handles) Rename(actorID int64, handle string) error {
+	if handle == "" {
+		return fmt.Errorf("invalid handle")
+	}
+
+	if _, exists := h.HandleToID[handle]; exists {
+		return fmt.Errorf("handle %q already exists", handle)
+	}
+
+	delete(h.HandleToID, h.IDToHandle[actorID])
+
+	h.HandleToID[handle] = actorID
+	h.IDToHandle[actorID] = handle
+
+	return nil
+}
+
+// Process applies an event of the log.
 func (s *SpaceValidation) Process(sourceActorID int64, event *proto.Event) error {
+	return s.process(sourceActorID, event, false)
+}
+
+// Submit applies an event that was never accepted, such as a new
+// submission: on top of the checks of Process, it is held to the rules that
+// only apply to submissions.
+func (s *SpaceValidation) Submit(sourceActorID int64, event *proto.Event) error {
+	return s.process(sourceActorID, event, true)
+}
+
+func (s *SpaceValidation) process(sourceActorID int64, event *proto.Event, submitted bool) error {
+	if _, deactivated := s.Deactivated[sourceActorID]; deactivated {
+		return fmt.Errorf("not authorized: actor is deactivated")
+	}
+
 	switch v := event.Msg.(type) {
 	case *proto.Event_SeedActor:
 		return s.Handles.Process(sourceActorID, v.SeedActor)
+	case *proto.Event_RenameActor:
+		actorID, exists := s.Handles.HandleToID[v.RenameActor.Handle]
+		if !exists {
+			return fmt.Errorf("actor does not exist")
+		}
+
+		if sourceActorID != actorID && !s.Permission.Can(sourceActorID, CapabilityManage) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		return s.Handles.Rename(actorID, v.RenameActor.NewHandle)
+	case *proto.Event_ActorActivation:
+		if !s.Permission.Can(sourceActorID, CapabilityManage) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		actorID, exists := s.Handles.HandleToID[v.ActorActivation.Handle]
+		if !exists {
+			return fmt.Errorf("actor does not exist")
+		}
+
+		if actorID == 0 {
+			return fmt.Errorf("root cannot be deactivated")
+		}
+
+		_, deactivated := s.Deactivated[actorID]
+
+		if v.ActorActivation.Reactivate {
+			if !deactivated {
+				return fmt.Errorf("actor %q is not deactivated", v.ActorActivation.Handle)
+			}
+
+			delete(s.Deactivated, actorID)
+
+			return nil
+		}
+
+		if deactivated {
+			return fmt.Errorf("actor %q is already deactivated", v.ActorActivation.Handle)
+		}
+
+		s.Deactivated[actorID] = struct{}{}
+
+		return nil
 	case *proto.Event_Permission:
-		return s.Permission.Process(sourceActorID, v.Permission)
+		return s.Permission.Process(sourceActorID, v.Permission, submitted)
 	case *proto.Event_SeedPlayer:
-		if s.Permission.Actors[sourceActorID] == PermissionOrga {
-			return fmt.Errorf("not authorized: orga not authorized to create players")
+		if !s.Permission.Can(sourceActorID, CapabilityPlay) {
+			return fmt.Errorf("not authorized: only players may create players")
 		}
 
 		actorID, exists := s.Handles.HandleToID[v.SeedPlayer.Handle]
@@ -80,7 +203,7 @@ func (s *SpaceValidation) Process(sourceActorID int64, event *proto.Event) error
 			return fmt.Errorf("not authorized: actor does not exist")
 		}
 
-		if sourceActorID != actorID && s.Permission.Actors[sourceActorID] != PermissionOrga {
+		if sourceActorID != actorID && !s.Permission.Can(sourceActorID, CapabilityEditPlayers) {
 			return fmt.Errorf("not authorized: missing permission")
 		}
 
@@ -96,10 +219,31 @@ func (s *SpaceValidation) Process(sourceActorID int64, event *proto.Event) error
 		if !exists {
 			return fmt.Errorf("player does not exist")
 		}
-		if sourceActorID != player.ActorID && s.Permission.Actors[sourceActorID] != PermissionOrga {
+		if sourceActorID != player.ActorID && !s.Permission.Can(sourceActorID, CapabilityEditPlayers) {
 			return fmt.Errorf("not authorized")
 		}
 
+		if v.PlayerPerson.MedicalRedacted && (v.PlayerPerson.Health != "" || v.PlayerPerson.EmergencyContact != "") {
+			return fmt.Errorf("medical fields set on a redacted registration")
+		}
+
+		person := withMedical(v.PlayerPerson, s.Medical)
+
+		if submitted {
+			err := CheckRegistration(person)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := checkVersion(s.PersonVersions, v.PlayerPerson.PlayerId, v.PlayerPerson.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		s.PersonVersions[v.PlayerPerson.PlayerId] = event.Ts
+		s.Medical[v.PlayerPerson.PlayerId] = MedicalFields{person.Health, person.EmergencyContact}
+
 		return nil
 
 	case *proto.Event_PlayerCharacter:
@@ -108,7 +252,7 @@ func (s *SpaceValidation) Process(sourceActorID int64, event *proto.Event) error
 			return fmt.Errorf("player does not exist")
 		}
 
-		if sourceActorID != player.ActorID && s.Permission.Actors[sourceActorID] != PermissionOrga {
+		if sourceActorID != player.ActorID && !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
 			return fmt.Errorf("not authorized")
 		}
 
@@ -116,32 +260,314 @@ func (s *SpaceValidation) Process(sourceActorID int64, event *proto.Event) error
 			return fmt.Errorf("character already exists")
 		}
 
+		if s.Reviews.State(v.PlayerCharacter.CharacterId) == ReviewApproved && !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
+			return fmt.Errorf("character is approved, changes must be requested first")
+		}
+
+		if v.PlayerCharacter.BypassRules {
+			if !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
+				return fmt.Errorf("not authorized: only orgas may bypass the univers rules")
+			}
+		} else if s.Univers != nil {
+			err := s.Univers.CheckCharacter(v.PlayerCharacter)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := checkVersion(s.CharacterVersions, v.PlayerCharacter.CharacterId, v.PlayerCharacter.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		err = s.Groups.Join(v.PlayerCharacter.CharacterId, v.PlayerCharacter.GroupId)
+		if err != nil {
+			return err
+		}
+
 		s.CharacterIDs[v.PlayerCharacter.CharacterId] = struct{ PlayerID string }{v.PlayerCharacter.PlayerId}
+		s.CharacterVersions[v.PlayerCharacter.CharacterId] = event.Ts
+
+		return nil
+	case *proto.Event_PlayerCharacterOrgaEdit:
+		if !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		if _, exists := s.CharacterIDs[v.PlayerCharacterOrgaEdit.CharacterId]; !exists {
+			return fmt.Errorf("character does not exist")
+		}
+
+		return nil
+	case *proto.Event_CharacterReview:
+		character, exists := s.CharacterIDs[v.CharacterReview.CharacterId]
+		if !exists {
+			return fmt.Errorf("character does not exist")
+		}
+
+		isPlayer := s.PlayersIDs[character.PlayerID].ActorID == sourceActorID
+
+		return s.Reviews.Process(isPlayer, s.Permission.Can(sourceActorID, CapabilityEditCharacters), v.CharacterReview)
+	case *proto.Event_Group:
+		if !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		return s.Groups.Define(v.Group)
+	case *proto.Event_Relationship:
+		if !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		return s.Relationships.Process(s.CharacterIDs, v.Relationship)
+	case *proto.Event_CharacterTransfer:
+		if !s.Permission.Can(sourceActorID, CapabilityEditCharacters) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		character, exists := s.CharacterIDs[v.CharacterTransfer.CharacterId]
+		if !exists {
+			return fmt.Errorf("character does not exist")
+		}
+
+		if _, exists := s.PlayersIDs[v.CharacterTransfer.PlayerId]; !exists {
+			return fmt.Errorf("player does not exist")
+		}
+
+		if character.PlayerID == v.CharacterTransfer.PlayerId {
+			return fmt.Errorf("character already belongs to player %q", v.CharacterTransfer.PlayerId)
+		}
+
+		s.CharacterIDs[v.CharacterTransfer.CharacterId] = struct{ PlayerID string }{v.CharacterTransfer.PlayerId}
+
+		return nil
+	case *proto.Event_PlayerErasure:
+		if !s.Permission.Can(sourceActorID, CapabilityEditPlayers) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		if _, exists := s.PlayersIDs[v.PlayerErasure.PlayerId]; !exists {
+			return fmt.Errorf("player does not exist")
+		}
+
+		delete(s.PersonVersions, v.PlayerErasure.PlayerId)
+		delete(s.Medical, v.PlayerErasure.PlayerId)
+
+		return nil
+	case *proto.Event_Erased:
+		if submitted {
+			return fmt.Errorf("not authorized: tombstones are only written by erasures")
+		}
+
+		if event.Ts >= v.Erased.ErasureTs {
+			return fmt.Errorf("invalid tombstone")
+		}
+
+		if v.Erased.Rejected {
+			return fmt.Errorf("erased event was not accepted")
+		}
+
+		return nil
+	case *proto.Event_Univers:
+		if !s.Permission.Can(sourceActorID, CapabilityManage) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		if len(v.Univers.Definition) == 0 {
+			s.Univers = nil
+
+			return nil
+		}
+
+		univers, err := ParseUnivers(v.Univers.Definition)
+		if err != nil {
+			return fmt.Errorf("invalid univers: %w", err)
+		}
+
+		s.Univers = univers
 
 		return nil
 	case *proto.Event_Reset_:
+		// Any actor could reset before the permissions were checked, the
+		// resets of the log stay accepted.
+		if submitted && !s.Permission.Can(sourceActorID, CapabilityManage) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		s.resetVersions()
+
+		return nil
+	case *proto.Event_Edition:
+		if submitted && !s.Permission.Can(sourceActorID, CapabilityManage) {
+			return fmt.Errorf("not authorized: missing permission")
+		}
+
+		if v.Edition.Name == "" || v.Edition.Name == EditionInitial {
+			return fmt.Errorf("invalid edition name")
+		}
+
+		if _, exists := s.Editions[v.Edition.Name]; exists {
+			return fmt.Errorf("edition %q already exists", v.Edition.Name)
+		}
+
+		s.Editions[v.Edition.Name] = struct{}{}
+		s.resetVersions()
+
 		return nil
 	default:
 		return fmt.Errorf("event %v not handled", v)
 	}
 }
 
+// resetVersions starts the versions over, the records of a new edition are
+// edited from scratch. The characters go back to draft, they are reviewed
+// again, join their groups again and have no relationship.
+func (s *SpaceValidation) resetVersions() {
+	s.PersonVersions = map[string]int64{}
+	s.CharacterVersions = map[string]int64{}
+	s.Reviews = Reviews{}
+	s.Groups.resetMembers()
+	s.Relationships = Relationships{}
+}
+
 type SpacePlayer struct {
-	Handle    string
-	ActorID   int64
-	Events    []*proto.Event
-	PlayerIDs map[string]struct{}
+	Handle       string
+	ActorID      int64
+	Events       []*proto.Event
+	PlayerIDs    map[string]struct{}
+	CharacterIDs map[string]struct{}
+	Medical      map[string]MedicalFields
+	// Characters holds the events of every character as its player sees
+	// them, they are handed over to the player a character is transferred to.
+	Characters map[string][]*proto.Event
+	// Relationships holds the last event of every relationship,
+	// KnownRelationships the ones the player of the actor knows about.
+	Relationships      map[string]*proto.Event
+	KnownRelationships map[string]struct{}
 }
 
 func NewSpacePlayer(actorID int64) *SpacePlayer {
 	return &SpacePlayer{
-		ActorID:   actorID,
-		PlayerIDs: map[string]struct{}{},
+		ActorID:      actorID,
+		PlayerIDs:    map[string]struct{}{},
+		CharacterIDs: map[string]struct{}{},
+		Medical:      map[string]MedicalFields{},
+		Characters:   map[string][]*proto.Event{},
+
+		Relationships:      map[string]*proto.Event{},
+		KnownRelationships: map[string]struct{}{},
+	}
+}
+
+// playerOrgaEdit returns the part of an orga edit the player of the character
+// is allowed to see: the public resume, the gifts, the handicaps and the
+// quests. The background, the mental crisis and the tags stay with the orgas.
+func playerOrgaEdit(event *proto.Event) *proto.Event {
+	edit := event.GetPlayerCharacterOrgaEdit()
+
+	return &proto.Event{
+		Ts: event.Ts,
+		Msg: &proto.Event_PlayerCharacterOrgaEdit{
+			PlayerCharacterOrgaEdit: &proto.EventPlayerCharacterOrgaEdit{
+				CharacterId:  edit.CharacterId,
+				PublicResume: edit.PublicResume,
+				Gitfs:        edit.Gitfs,
+				Handicaps:    edit.Handicaps,
+				Quests:       edit.Quests,
+			},
+		},
+	}
+}
+
+// erasePersons drops the EventPlayerPerson of playerID from the events of a
+// projection built before they were erased from the log. The events are
+// copied, fetches may still hold the previous slice.
+func erasePersons(events []*proto.Event, playerID string) []*proto.Event {
+	kept := make([]*proto.Event, 0, len(events))
+
+	for _, event := range events {
+		if event.GetPlayerPerson().GetPlayerId() != playerID {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept
+}
+
+// characterID returns the character an event is about.
+func characterID(event *proto.Event) string {
+	switch v := event.Msg.(type) {
+	case *proto.Event_PlayerCharacter:
+		return v.PlayerCharacter.CharacterId
+	case *proto.Event_PlayerCharacterOrgaEdit:
+		return v.PlayerCharacterOrgaEdit.CharacterId
+	case *proto.Event_CharacterReview:
+		return v.CharacterReview.CharacterId
+	default:
+		return ""
 	}
 }
 
-func (s *SpacePlayer) GetEvents() []*proto.Event {
-	return s.Events
+// dropCharacter removes the events of a character transferred away from the
+// events of a projection. The events are copied, see erasePersons.
+func dropCharacter(events []*proto.Event, id string) []*proto.Event {
+	kept := make([]*proto.Event, 0, len(events))
+
+	for _, event := range events {
+		if characterID(event) != id {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept
+}
+
+// mergeEvents merges events older than the last one of a projection into its
+// events, in ts order.
+func mergeEvents(events []*proto.Event, older []*proto.Event) []*proto.Event {
+	merged := make([]*proto.Event, 0, len(events)+len(older))
+
+	for _, event := range older {
+		for len(events) > 0 && events[0].Ts < event.Ts {
+			merged = append(merged, events[0])
+			events = events[1:]
+		}
+
+		merged = append(merged, event)
+	}
+
+	return append(merged, events...)
+}
+
+// receiveCharacter merges the history of a character transferred to playerID
+// into the events of a projection. The saves of the character are rewritten
+// as saves of playerID so that the previous player stays hidden.
+func receiveCharacter(events []*proto.Event, history []*proto.Event, playerID string) []*proto.Event {
+	rewritten := make([]*proto.Event, len(history))
+
+	for i, event := range history {
+		rewritten[i] = event
+
+		if character := event.GetPlayerCharacter(); character != nil {
+			save := protolib.Clone(character).(*proto.EventPlayerCharacter)
+			save.PlayerId = playerID
+			rewritten[i] = &proto.Event{Ts: event.Ts, Msg: &proto.Event_PlayerCharacter{PlayerCharacter: save}}
+		}
+	}
+
+	return mergeEvents(events, rewritten)
+}
+
+// eventsAfter returns the events with a ts greater than from, events being
+// in ts order.
+func eventsAfter(events []*proto.Event, from int64) []*proto.Event {
+	return events[sort.Search(len(events), func(i int) bool {
+		return events[i].Ts > from
+	}):]
+}
+
+func (s *SpacePlayer) GetEvents(from int64) []*proto.Event {
+	return eventsAfter(s.Events, from)
 }
 
 func (s *SpacePlayer) Process(sourceActorID int64, event *proto.Event) error {
@@ -154,6 +580,13 @@ func (s *SpacePlayer) Process(sourceActorID int64, event *proto.Event) error {
 
 		return nil
 
+	case *proto.Event_RenameActor:
+		if s.Handle != "" && s.Handle == v.RenameActor.Handle {
+			s.Handle = v.RenameActor.NewHandle
+			s.Events = append(s.Events, event)
+		}
+
+		return nil
 	case *proto.Event_SeedPlayer:
 		if s.Handle == v.SeedPlayer.Handle {
 			s.Events = append(s.Events, event)
@@ -163,19 +596,84 @@ func (s *SpacePlayer) Process(sourceActorID int64, event *proto.Event) error {
 		return nil
 	case *proto.Event_PlayerPerson:
 		if _, exists := s.PlayerIDs[v.PlayerPerson.PlayerId]; exists {
-			s.Events = append(s.Events, event)
+			s.Events = append(s.Events, projectPerson(event, s.Medical))
 		}
 
 		return nil
 	case *proto.Event_PlayerCharacter:
+		s.Characters[v.PlayerCharacter.CharacterId] = append(s.Characters[v.PlayerCharacter.CharacterId], event)
+
 		if _, exists := s.PlayerIDs[v.PlayerCharacter.PlayerId]; exists {
 			s.Events = append(s.Events, event)
+			s.CharacterIDs[v.PlayerCharacter.CharacterId] = struct{}{}
 		}
 
 		return nil
-	case *proto.Event_Permission:
+	case *proto.Event_PlayerCharacterOrgaEdit:
+		edit := playerOrgaEdit(event)
+		s.Characters[v.PlayerCharacterOrgaEdit.CharacterId] = append(s.Characters[v.PlayerCharacterOrgaEdit.CharacterId], edit)
+
+		if _, exists := s.CharacterIDs[v.PlayerCharacterOrgaEdit.CharacterId]; exists {
+			s.Events = append(s.Events, edit)
+		}
+
 		return nil
-	case *proto.Event_Reset_:
+	case *proto.Event_CharacterReview:
+		s.Characters[v.CharacterReview.CharacterId] = append(s.Characters[v.CharacterReview.CharacterId], event)
+
+		if _, exists := s.CharacterIDs[v.CharacterReview.CharacterId]; exists {
+			s.Events = append(s.Events, event)
+		}
+
+		return nil
+	case *proto.Event_CharacterTransfer:
+		id := v.CharacterTransfer.CharacterId
+		_, owned := s.CharacterIDs[id]
+		_, receives := s.PlayerIDs[v.CharacterTransfer.PlayerId]
+
+		switch {
+		case owned && receives:
+			s.Events = append(s.Events, event)
+		case owned:
+			s.Events = append(dropCharacter(s.Events, id), event)
+			delete(s.CharacterIDs, id)
+		case receives:
+			s.Events = append(receiveCharacter(s.Events, s.Characters[id], v.CharacterTransfer.PlayerId), event)
+			s.CharacterIDs[id] = struct{}{}
+		}
+
+		s.refreshRelationships(event.Ts, "")
+
+		return nil
+	case *proto.Event_Relationship:
+		if v.Relationship.Remove {
+			delete(s.Relationships, v.Relationship.RelationshipId)
+		} else {
+			s.Relationships[v.Relationship.RelationshipId] = event
+		}
+
+		s.refreshRelationships(event.Ts, v.Relationship.RelationshipId)
+
+		return nil
+	case *proto.Event_PlayerErasure:
+		if _, exists := s.PlayerIDs[v.PlayerErasure.PlayerId]; exists {
+			s.Events = append(erasePersons(s.Events, v.PlayerErasure.PlayerId), event)
+			delete(s.Medical, v.PlayerErasure.PlayerId)
+		}
+
+		return nil
+	case *proto.Event_Permission, *proto.Event_Univers, *proto.Event_ActorActivation, *proto.Event_Erased:
+		return nil
+	case *proto.Event_Reset_, *proto.Event_Edition:
+		// The relationships of the previous edition are not brought back by
+		// the transfers of the new one.
+		s.Relationships = map[string]*proto.Event{}
+		s.KnownRelationships = map[string]struct{}{}
+		s.Events = append(s.Events, event)
+
+		return nil
+	case *proto.Event_Group:
+		// Players pick the group of their characters.
 		s.Events = append(s.Events, event)
 
 		return nil
@@ -187,22 +685,55 @@ func (s *SpacePlayer) Process(sourceActorID int64, event *proto.Event) error {
 
 type ProjectionSpace interface {
 	Process(sourceActorID int64, event *proto.Event) error
-	GetEvents() []*proto.Event
+	// GetEvents returns the events of the projection after from.
+	GetEvents(from int64) []*proto.Event
 }
 
 type SpaceOrga struct {
 	ActorID int64
+	Handle  string
 	Events  []*proto.Event
+	// Own holds the ts of the events about the actor itself, its handle and
+	// its role, which it gets whatever its capabilities.
+	Own map[int64]struct{}
+	// Permission follows the roles so that the events are filtered by the
+	// capabilities the actor has when it fetches them.
+	Permission Permission
+	Medical    map[string]MedicalFields
 }
 
 func NewSpaceOrga(actorID int64) *SpaceOrga {
 	return &SpaceOrga{
 		ActorID: actorID,
+		Own:     map[int64]struct{}{},
+		Permission: Permission{
+			Actors: map[int64]string{
+				0: PermissionRoot,
+			},
+		},
+		Medical: map[string]MedicalFields{},
 	}
 }
 
-func (s *SpaceOrga) GetEvents() []*proto.Event {
-	return s.Events
+func (s *SpaceOrga) GetEvents(from int64) []*proto.Event {
+	after := eventsAfter(s.Events, from)
+	events := make([]*proto.Event, 0, len(after))
+
+	for _, event := range after {
+		_, own := s.Own[event.Ts]
+
+		if read, _ := EventCapabilities(event); !own && !s.Permission.Can(s.ActorID, read) {
+			continue
+		}
+
+		if event.GetPlayerPerson() != nil && !s.Permission.Can(s.ActorID, CapabilityReadMedical) {
+			event = redactMedical(event)
+		}
+
+		events = append(events, event)
+	}
+
+	return events
 }
 
 func (s *SpaceOrga) Process(sourceActorID int64, event *proto.Event) error {
@@ -212,18 +743,64 @@ func (s *SpaceOrga) Process(sourceActorID int64, event *proto.Event) error {
 
 		return nil
 	case *proto.Event_PlayerPerson:
-		s.Events = append(s.Events, event)
+		s.Events = append(s.Events, projectPerson(event, s.Medical))
 
 		return nil
 	case *proto.Event_PlayerCharacter:
 		s.Events = append(s.Events, event)
 
 		return nil
-	case *proto.Event_SeedActor, *proto.Event_Permission:
+	case *proto.Event_PlayerCharacterOrgaEdit, *proto.Event_CharacterReview, *proto.Event_CharacterTransfer, *proto.Event_Group, *proto.Event_Relationship:
 		s.Events = append(s.Events, event)
 
 		return nil
-	case *proto.Event_Reset_:
+	case *proto.Event_SeedActor:
+		if sourceActorID == s.ActorID {
+			s.Handle = v.SeedActor.Handle
+			s.Own[event.Ts] = struct{}{}
+		}
+
+		s.Events = append(s.Events, event)
+
+		return nil
+	case *proto.Event_RenameActor:
+		if s.Handle != "" && v.RenameActor.Handle == s.Handle {
+			s.Handle = v.RenameActor.NewHandle
+			s.Own[event.Ts] = struct{}{}
+		}
+
+		s.Events = append(s.Events, event)
+
+		return nil
+	case *proto.Event_ActorActivation:
+		if s.Handle != "" && v.ActorActivation.Handle == s.Handle {
+			s.Own[event.Ts] = struct{}{}
+		}
+
+		s.Events = append(s.Events, event)
+
+		return nil
+	case *proto.Event_Permission:
+		s.Permission.apply(v.Permission)
+
+		if v.Permission.ActorId == s.ActorID {
+			s.Own[event.Ts] = struct{}{}
+		}
+
+		s.Events = append(s.Events, event)
+
+		return nil
+	case *proto.Event_PlayerErasure:
+		s.Events = append(erasePersons(s.Events, v.PlayerErasure.PlayerId), event)
+		delete(s.Medical, v.PlayerErasure.PlayerId)
+
+		return nil
+	case *proto.Event_Univers:
+		// The app loads the univers on its own.
+		return nil
+	case *proto.Event_Erased:
+		return nil
+	case *proto.Event_Reset_, *proto.Event_Edition:
 		s.Events = append(s.Events, event)
 
 		return nil
@@ -1,135 +1,563 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/ebenaum/thekeeper/proto"
-	"github.com/jmoiron/sqlx"
 )
 
 type RunEventResult struct {
 	Ts     int64             `json:"ts"`
 	Status EventRecordStatus `json:"status"`
 	Error  string            `json:"error,omitempty"`
+	// Conflict tells the client the event was based on a stale version.
+	Conflict bool `json:"conflict,omitempty"`
+	// Fields maps the fields of a rejected registration to the rule they
+	// break, for the form to highlight them.
+	Fields FieldErrors `json:"fields,omitempty"`
 }
 
-func Run(db *sqlx.DB, tsResultsToInclude map[int64]bool) ([]RunEventResult, error) {
-	space := NewSpaceValidation()
+// Validation keeps a SpaceValidation in sync with the events table between
+// requests, so that only the events inserted since the last run have to be
+// processed.
+type Validation struct {
+	mu     sync.Mutex
+	space  SpaceValidation
+	lastTs int64
+	count  int
+	synced bool
 
-	results := make([]RunEventResult, 0)
+	// view is what the readers see of space, published after each commit
+	// so that they do not wait for mu.
+	viewMu sync.RWMutex
+	view   *validationView
+
+	// DiscardRejected drops the inserted events that are rejected instead of
+	// keeping them in the log.
+	DiscardRejected bool
+	// IdempotencyWindow is how long the results of a batch with an
+	// idempotency key are kept for its retries.
+	IdempotencyWindow time.Duration
+	// HeadKey signs the head of the log returned with the fetched events,
+	// nil leaves it unsigned.
+	HeadKey *ecdsa.PrivateKey
+	now     func() time.Time
+}
+
+func NewValidation() *Validation {
+	return &Validation{
+		IdempotencyWindow: 24 * time.Hour,
+		now:               time.Now,
+	}
+}
 
-	records, err := GetEvents(db, -1, EventRecordStatusAll)
+// Run validates the events inserted since the previous call and returns the
+// results of the events in tsResultsToInclude. It falls back to a full replay
+// when the log changed before the last processed event or when a new edition
+// is opened.
+func (v *Validation) Run(store EventStore, tsResultsToInclude map[int64]bool) ([]RunEventResult, error) {
+	if !v.synced {
+		return v.replay(store, tsResultsToInclude)
+	}
+
+	count, err := store.CountEvents(v.lastTs, EventRecordStatusAll)
 	if err != nil {
-		return nil, fmt.Errorf("get events: %w", err)
+		return nil, fmt.Errorf("count events: %w", err)
 	}
 
-	toUpdate := map[int64]EventRecordStatus{}
+	if count != v.count {
+		return v.replay(store, tsResultsToInclude)
+	}
+
+	records, err := store.GetEvents(v.lastTs, EventRecordStatusAll)
+	if err != nil {
+		return nil, fmt.Errorf("get events: %w", err)
+	}
 
 	for _, record := range records {
-		err := space.Process(record.SourceActorID, &record.Event)
-		if err != nil && record.Status&(EventRecordStatusPending|EventRecordStatusRejected) == 0 {
-			return nil, fmt.Errorf(
-				"corrupted state: event %d has status %v. Process returned: %w",
-				record.Event.Ts,
-				record.Status,
-				err,
-			)
+		if _, ok := EditionName(record.Event); ok {
+			return v.replay(store, tsResultsToInclude)
 		}
+	}
 
-		var newStatus EventRecordStatus
+	return v.process(store, records, tsResultsToInclude)
+}
+
+func (v *Validation) replay(store EventStore, tsResultsToInclude map[int64]bool) ([]RunEventResult, error) {
+	v.space = NewSpaceValidation()
+	v.lastTs = -1
+	v.count = 0
+	v.synced = false
+
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		return nil, fmt.Errorf("get events: %w", err)
+	}
 
+	return v.process(store, records, tsResultsToInclude)
+}
+
+func (v *Validation) process(store EventStore, records []EventRecord, tsResultsToInclude map[int64]bool) ([]RunEventResult, error) {
+	v.synced = false
+
+	results, err := processRecords(store, &v.space, records, tsResultsToInclude, v.DiscardRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 {
+		v.lastTs = records[len(records)-1].Event.Ts
+	}
+
+	// The discarded events left the log, the count is read back from it.
+	v.count, err = store.CountEvents(v.lastTs, EventRecordStatusAll)
+	if err != nil {
+		return nil, fmt.Errorf("count events: %w", err)
+	}
+
+	v.synced = true
+
+	return results, nil
+}
+
+// validationView is a copy of the parts of a SpaceValidation the requests
+// read, as of the event at lastTs. It is never modified once published.
+type validationView struct {
+	lastTs      int64
+	handles     map[string]int64
+	deactivated map[int64]struct{}
+	permission  Permission
+	groups      []GroupMembership
+}
+
+// publish copies the space for the readers. It is called under mu, once
+// the events processed are committed.
+func (v *Validation) publish() {
+	if !v.synced {
+		return
+	}
+
+	view := &validationView{
+		lastTs:      v.lastTs,
+		handles:     maps.Clone(v.space.Handles.HandleToID),
+		deactivated: maps.Clone(v.space.Deactivated),
+		permission:  v.space.Permission.clone(),
+		groups:      v.space.Groups.list(),
+	}
+
+	v.viewMu.Lock()
+	v.view = view
+	v.viewMu.Unlock()
+}
+
+// read calls fn with the last published view. The log is only run, under
+// the lock of the validation, when it holds events the view has not seen,
+// such as the ones of another process.
+func (v *Validation) read(store EventStore, fn func(view *validationView)) error {
+	v.viewMu.RLock()
+	view := v.view
+	v.viewMu.RUnlock()
+
+	if view != nil {
+		records, err := store.GetEvents(view.lastTs, EventRecordStatusAll)
 		if err != nil {
-			newStatus = EventRecordStatusRejected
+			return fmt.Errorf("get events: %w", err)
+		}
+
+		if len(records) == 0 {
+			fn(view)
+
+			return nil
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	err := store.Atomic(func(store EventStore) error {
+		_, err := v.Run(store, nil)
+
+		return err
+	})
+	if err != nil {
+		v.synced = false
+
+		return fmt.Errorf("run: %w", err)
+	}
+
+	v.publish()
+
+	fn(v.view)
+
+	return nil
+}
+
+// ResolveStutteringEvent settles the stuttering event at ts as resolved once
+// authorize accepts it, given the capabilities of actorID. It returns
+// sql.ErrNoRows when the event is not stuttering, and the error of authorize.
+func (v *Validation) ResolveStutteringEvent(store EventStore, actorID int64, ts int64, authorize func(record StutteringEventRecord, capabilities Capability) error) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	err := store.Atomic(func(store EventStore) error {
+		_, err := v.Run(store, nil)
+		if err != nil {
+			return fmt.Errorf("run: %w", err)
+		}
+
+		records, err := store.GetStutteringEvents()
+		if err != nil {
+			return fmt.Errorf("get stuttering events: %w", err)
+		}
+
+		index := slices.IndexFunc(records, func(record StutteringEventRecord) bool { return record.Event.Ts == ts })
+		if index == -1 {
+			return sql.ErrNoRows
+		}
+
+		err = authorize(records[index], v.space.Permission.Capabilities(actorID))
+		if err != nil {
+			return err
+		}
+
+		return store.ResolveStutteringEvent(ts)
+	})
+	if err != nil {
+		v.synced = false
+
+		return err
+	}
+
+	v.publish()
+
+	return nil
+}
+
+// Run replays every event of the log on a fresh SpaceValidation.
+func Run(store EventStore, tsResultsToInclude map[int64]bool) ([]RunEventResult, error) {
+	space := NewSpaceValidation()
+
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		return nil, fmt.Errorf("get events: %w", err)
+	}
+
+	return processRecords(store, &space, records, tsResultsToInclude, false)
+}
+
+type statusUpdate struct {
+	status EventRecordStatus
+	reason string
+}
+
+// processRecords runs records on space and stores their new status. With
+// discardRejected, the rejected events of tsResultsToInclude are deleted
+// instead. The erasures that become accepted rewrite the history of their
+// player.
+func processRecords(store EventStore, space *SpaceValidation, records []EventRecord, tsResultsToInclude map[int64]bool, discardRejected bool) ([]RunEventResult, error) {
+	results := make([]RunEventResult, 0)
+
+	toUpdate := map[int64]statusUpdate{}
+
+	var discarded []int64
+
+	var erasures []*proto.Event
+
+	for _, record := range records {
+		// The orgas settled the resolved events, they stay out of the space.
+		if record.Status == EventRecordStatusResolved {
+			if tsResultsToInclude[record.Event.Ts] {
+				results = append(results, RunEventResult{Ts: record.Event.Ts, Status: record.Status})
+			}
+
+			continue
+		}
+
+		var err error
+
+		// The events accepted once replay as such, the pending and rejected
+		// ones are still held to the rules of new submissions.
+		if record.Status&(EventRecordStatusAccepted|EventRecordStatusStuttering) != 0 {
+			err = space.Process(record.SourceActorID, record.Event)
 		} else {
+			err = space.Submit(record.SourceActorID, record.Event)
+		}
+
+		var newStatus EventRecordStatus
+
+		switch {
+		case err == nil:
 			newStatus = EventRecordStatusAccepted
+		case record.Status&(EventRecordStatusAccepted|EventRecordStatusStuttering) != 0:
+			newStatus = EventRecordStatusStuttering
+		default:
+			newStatus = EventRecordStatusRejected
 		}
 
-		if tsResultsToInclude[record.Event.Ts] {
-			result := RunEventResult{
-				record.Event.Ts,
-				newStatus,
-				"",
-			}
+		result := RunEventResult{
+			Ts:     record.Event.Ts,
+			Status: newStatus,
+		}
 
-			if err != nil {
-				result.Error = err.Error()
-			}
+		if err != nil {
+			result.Error = err.Error()
+			result.Conflict = errors.Is(err, ErrConflict)
+			errors.As(err, &result.Fields)
+		}
 
+		if tsResultsToInclude[record.Event.Ts] {
 			results = append(results, result)
 		}
 
+		if discardRejected && tsResultsToInclude[record.Event.Ts] && newStatus == EventRecordStatusRejected {
+			discarded = append(discarded, record.Event.Ts)
+
+			continue
+		}
+
 		if newStatus != record.Status {
-			toUpdate[record.Event.Ts] = newStatus
+			toUpdate[record.Event.Ts] = statusUpdate{newStatus, result.Error}
+
+			if newStatus == EventRecordStatusAccepted && record.Event.GetPlayerErasure() != nil {
+				erasures = append(erasures, record.Event)
+			}
+		}
+	}
+
+	// Rejected events are dropped before the erasures, the rewrites they
+	// append are chained after the last event.
+	err := store.DeleteEvents(discarded)
+	if err != nil {
+		return nil, fmt.Errorf("delete rejected events: %w", err)
+	}
+
+	for ts, update := range toUpdate {
+		err := store.UpdateEventStatus(ts, update.status, update.reason)
+		if err != nil {
+			return nil, fmt.Errorf("updating event %d to status %v: %w", ts, update.status, err)
 		}
 	}
 
-	for ts, status := range toUpdate {
-		err = UpdateEventStatus(db, ts, status)
+	for _, event := range erasures {
+		erasure := event.GetPlayerErasure()
+
+		err = ErasePlayer(store, erasure.PlayerId, event.Ts)
 		if err != nil {
-			return nil, fmt.Errorf("updating event %d to status %v: %w", ts, status, err)
+			return nil, fmt.Errorf("erase player %q: %w", erasure.PlayerId, err)
 		}
 	}
 
 	return results, nil
 }
 
-func FetchEvents(db *sqlx.DB, sourceActorID int64, space ActorSpace, from int64) ([]*proto.Event, error) {
-	var projection ProjectionSpace
+type projectionKey struct {
+	actorID int64
+	space   ActorSpace
+}
+
+// ProjectionSnapshot is the projection of an actor built from the accepted
+// events up to LastTs.
+type ProjectionSnapshot struct {
+	mu         sync.Mutex
+	projection ProjectionSpace
+	LastTs     int64
+	Count      int
+}
+
+// Projections keeps a ProjectionSnapshot per actor so that a fetch only has
+// to apply the events accepted since the previous one.
+type Projections struct {
+	mu        sync.Mutex
+	snapshots map[projectionKey]*ProjectionSnapshot
+}
+
+func NewProjections() *Projections {
+	return &Projections{
+		snapshots: map[projectionKey]*ProjectionSnapshot{},
+	}
+}
 
+func newProjection(sourceActorID int64, space ActorSpace) ProjectionSpace {
 	if space == ActorSpaceOrga {
-		projection = NewSpaceOrga(sourceActorID)
-	} else {
-		projection = NewSpacePlayer(sourceActorID)
+		return NewSpaceOrga(sourceActorID)
+	}
+
+	return NewSpacePlayer(sourceActorID)
+}
+
+func (p *Projections) snapshot(sourceActorID int64, space ActorSpace) *ProjectionSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := projectionKey{sourceActorID, space}
+
+	snapshot, exists := p.snapshots[key]
+	if !exists {
+		snapshot = &ProjectionSnapshot{
+			projection: newProjection(sourceActorID, space),
+			LastTs:     -1,
+		}
+		p.snapshots[key] = snapshot
 	}
 
-	records, err := GetEvents(db, -1, EventRecordStatusAccepted)
+	return snapshot
+}
+
+// update applies the accepted events newer than the snapshot. The snapshot
+// is rebuilt from scratch when accepted events showed up before LastTs.
+func (s *ProjectionSnapshot) update(store EventStore, sourceActorID int64, space ActorSpace) error {
+	count, err := store.CountEvents(s.LastTs, EventRecordStatusAccepted)
 	if err != nil {
-		return nil, fmt.Errorf("get events: %w", err)
+		return fmt.Errorf("count events: %w", err)
+	}
+
+	if count != s.Count {
+		s.projection = newProjection(sourceActorID, space)
+		s.LastTs = -1
+		s.Count = 0
+	}
+
+	records, err := store.GetEvents(s.LastTs, EventRecordStatusAccepted)
+	if err != nil {
+		return fmt.Errorf("get events: %w", err)
 	}
 
 	for _, record := range records {
-		err := projection.Process(record.SourceActorID, &record.Event)
+		err := s.projection.Process(record.SourceActorID, record.Event)
 		if err != nil {
-			return nil, fmt.Errorf(
+			s.projection = newProjection(sourceActorID, space)
+			s.LastTs = -1
+			s.Count = 0
+
+			return fmt.Errorf(
 				"corrupted state: event %d has status %v. Process returned: %w",
 				record.Event.Ts,
 				record.Status,
 				err,
 			)
 		}
+
+		s.LastTs = record.Event.Ts
+		s.Count++
 	}
 
-	var cursor int
-	events := projection.GetEvents()
+	return nil
+}
 
-	for cursor = range events {
-		if events[cursor].Ts > from {
-			break
+// FetchEvents returns the events of the actor projection after from, scoped
+// to edition. An empty edition stands for the current one.
+func FetchEvents(store EventStore, projections *Projections, sourceActorID int64, space ActorSpace, edition string, from int64) ([]*proto.Event, error) {
+	snapshot := projections.snapshot(sourceActorID, space)
+
+	snapshot.mu.Lock()
+	defer snapshot.mu.Unlock()
+
+	err := snapshot.update(store, sourceActorID, space)
+	if err != nil {
+		return nil, err
+	}
+
+	// The events after from are scoped to the current edition as they are
+	// in the whole log, only another edition needs the events before from.
+	if edition == "" {
+		events, err := ScopeEdition(snapshot.projection.GetEvents(from), edition)
+		if err != nil || len(events) == 0 {
+			return nil, err
 		}
+
+		return events, nil
 	}
 
-	if len(events) > 0 && events[cursor].Ts == from {
+	events, err := ScopeEdition(snapshot.projection.GetEvents(-1), edition)
+	if err != nil {
+		return nil, err
+	}
+
+	events = eventsAfter(events, from)
+	if len(events) == 0 {
 		return nil, nil
 	}
 
-	return events[cursor:], nil
+	return events, nil
 }
 
-func InsertAndCheckEvents(db *sqlx.DB, from int64, sourceActorID int64, newEvents []*proto.Event) ([]RunEventResult, error) {
-	tss, err := InsertEvents(db, sourceActorID, newEvents)
-	if err != nil {
-		return nil, fmt.Errorf("insert events: %w", err)
-	}
+// InsertAndCheckEvents inserts the events, validates them and stores their
+// status in a single atomic call of store. A batch already submitted with
+// the same idempotency key gets the results of its first submission back
+// and nothing is inserted.
+func InsertAndCheckEvents(store EventStore, validation *Validation, from int64, sourceActorID int64, batch *Batch, newEvents []*proto.Event) ([]RunEventResult, error) {
+	validation.mu.Lock()
+	defer validation.mu.Unlock()
+
+	var result []RunEventResult
 
-	tssMap := map[int64]bool{}
-	for _, ts := range tss {
-		tssMap[ts] = true
+	var idempotencyKey string
+	if batch != nil {
+		idempotencyKey = batch.IdempotencyKey
 	}
 
-	result, err := Run(db, tssMap)
+	now := validation.now().Unix()
+	since := now - int64(validation.IdempotencyWindow.Seconds())
+
+	err := store.Atomic(func(store EventStore) error {
+		if idempotencyKey != "" {
+			results, err := store.GetIdempotentResults(sourceActorID, idempotencyKey, since)
+			if err == nil {
+				result = results
+
+				return nil
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("get idempotent results: %w", err)
+			}
+		}
+
+		tss, err := store.InsertEvents(sourceActorID, batch, newEvents)
+		if err != nil {
+			return fmt.Errorf("insert events: %w", err)
+		}
+
+		tssMap := map[int64]bool{}
+		for _, ts := range tss {
+			tssMap[ts] = true
+		}
+
+		result, err = validation.Run(store, tssMap)
+		if err != nil {
+			return fmt.Errorf("run: %w", err)
+		}
+
+		if idempotencyKey == "" {
+			return nil
+		}
+
+		err = store.DeleteIdempotencyKeys(since)
+		if err != nil {
+			return fmt.Errorf("delete idempotency keys: %w", err)
+		}
+
+		err = store.InsertIdempotentResults(sourceActorID, idempotencyKey, now, result)
+		if err != nil {
+			return fmt.Errorf("insert idempotent results: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("run: %w", err)
+		// The validation may hold events that were rolled back.
+		validation.synced = false
+
+		return nil, err
 	}
 
+	validation.publish()
+
 	return result, nil
 }
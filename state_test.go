@@ -0,0 +1,620 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+	protolib "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func openTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "thekeeper.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	_, err = Migrate(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewSQLiteStore(db)
+}
+
+// newTestStore returns a store holding an orga actor 1 and player actors 2
+// and 3.
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	store := openTestStore(t)
+
+	for _, space := range []ActorSpace{ActorSpaceOrga, ActorSpacePlayer, ActorSpacePlayer} {
+		_, err := store.InsertActor(space)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return store
+}
+
+func seedActor(handle string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_SeedActor{SeedActor: &proto.EventSeedActor{Handle: handle}}}
+}
+
+func seedPlayer(handle, playerID string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_SeedPlayer{SeedPlayer: &proto.EventSeedPlayer{Handle: handle, PlayerId: playerID}}}
+}
+
+func playerCharacter(playerID, characterID string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_PlayerCharacter{PlayerCharacter: &proto.EventPlayerCharacter{PlayerId: playerID, CharacterId: characterID}}}
+}
+
+func storedStatuses(t *testing.T, store EventStore) map[int64]EventRecordStatus {
+	t.Helper()
+
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := map[int64]EventRecordStatus{}
+	for _, record := range records {
+		statuses[record.Event.Ts] = record.Status
+	}
+
+	return statuses
+}
+
+func TestValidationIncrementalMatchesReplay(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	type batch struct {
+		sourceActorID int64
+		events        []*proto.Event
+	}
+
+	batches := []batch{
+		{1, []*proto.Event{seedActor("benoit")}},
+		{0, []*proto.Event{{Msg: &proto.Event_Permission{Permission: &proto.EventPermission{ActorId: 1, Permission: PermissionOrga}}}}},
+		{2, []*proto.Event{seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art")}},
+		{3, []*proto.Event{seedActor("art-coffee"), seedActor("tea-grumpy")}},
+		{3, []*proto.Event{seedPlayer("tea-grumpy", "player:grumpy-tea"), playerCharacter("player:grumpy-tea", "character:1")}},
+		{2, []*proto.Event{playerCharacter("player:coffee-art", "character:1"), playerCharacter("player:coffee-art", "character:2")}},
+		{0, []*proto.Event{{Msg: &proto.Event_Reset_{}}}},
+		{3, []*proto.Event{playerCharacter("player:coffee-art", "character:3")}},
+	}
+
+	for i, b := range batches {
+		if i == 5 {
+			// An event older than everything already validated forces a full replay.
+			early := seedActor("early")
+			early.Ts = 1
+
+			data, err := protolib.Marshal(early)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = store.db.Exec(`INSERT INTO events (ts, source_actor_id, data, status) VALUES (1, 0, ?, ?)`, data, EventRecordStatusPending)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		results, err := InsertAndCheckEvents(store, validation, -1, b.sourceActorID, nil, b.events)
+		if err != nil {
+			t.Fatalf("batch #%d: %v", i, err)
+		}
+
+		stored := storedStatuses(t, store)
+
+		all := map[int64]bool{}
+		for ts := range stored {
+			all[ts] = true
+		}
+
+		replayed, err := Run(store, all)
+		if err != nil {
+			t.Fatalf("batch #%d: replay: %v", i, err)
+		}
+
+		replayedStatuses := map[int64]EventRecordStatus{}
+		for _, result := range replayed {
+			replayedStatuses[result.Ts] = result.Status
+		}
+
+		if diff := cmp.Diff(replayedStatuses, stored); diff != "" {
+			t.Fatalf("batch #%d: stored statuses differ from replay (-replay +stored):\n%s", i, diff)
+		}
+
+		for _, result := range results {
+			if result.Status != replayedStatuses[result.Ts] {
+				t.Fatalf("batch #%d: event %d is %v, replay says %v", i, result.Ts, result.Status, replayedStatuses[result.Ts])
+			}
+		}
+
+		if len(results) != len(b.events) {
+			t.Fatalf("batch #%d: got %d results for %d events", i, len(results), len(b.events))
+		}
+	}
+}
+
+func TestFetchEventsSnapshotMatchesFreshProjection(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+	projections := NewProjections()
+
+	type batch struct {
+		sourceActorID int64
+		events        []*proto.Event
+	}
+
+	batches := []batch{
+		{1, []*proto.Event{seedActor("benoit")}},
+		{0, []*proto.Event{{Msg: &proto.Event_Permission{Permission: &proto.EventPermission{ActorId: 1, Permission: PermissionOrga}}}}},
+		{2, []*proto.Event{seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art")}},
+		{3, []*proto.Event{seedActor("tea-grumpy"), seedPlayer("tea-grumpy", "player:grumpy-tea")}},
+		{2, []*proto.Event{playerCharacter("player:coffee-art", "character:1")}},
+		{3, []*proto.Event{playerCharacter("player:grumpy-tea", "character:2")}},
+	}
+
+	viewers := []struct {
+		actorID int64
+		space   ActorSpace
+	}{
+		{1, ActorSpaceOrga},
+		{2, ActorSpacePlayer},
+		{3, ActorSpacePlayer},
+	}
+
+	for i, b := range batches {
+		if i == 4 {
+			// An accepted event older than the snapshots forces a rebuild.
+			early := &proto.Event{Ts: 1, Msg: &proto.Event_Reset_{}}
+
+			data, err := protolib.Marshal(early)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = store.db.Exec(`INSERT INTO events (ts, source_actor_id, data, status) VALUES (1, 0, ?, ?)`, data, EventRecordStatusAccepted)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := InsertAndCheckEvents(store, validation, -1, b.sourceActorID, nil, b.events)
+		if err != nil {
+			t.Fatalf("batch #%d: %v", i, err)
+		}
+
+		for _, viewer := range viewers {
+			for _, from := range []int64{-1, 1} {
+				got, err := FetchEvents(store, projections, viewer.actorID, viewer.space, "", from)
+				if err != nil {
+					t.Fatalf("batch #%d: %v", i, err)
+				}
+
+				want, err := FetchEvents(store, NewProjections(), viewer.actorID, viewer.space, "", from)
+				if err != nil {
+					t.Fatalf("batch #%d: %v", i, err)
+				}
+
+				if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+					t.Fatalf("batch #%d: actor %d from %d: snapshot differs from fresh projection (-fresh +snapshot):\n%s", i, viewer.actorID, from, diff)
+				}
+			}
+		}
+	}
+}
+
+func TestRunMarksFlippedEventsStuttering(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	results, err := InsertAndCheckEvents(store, validation, -1, 2, nil, []*proto.Event{seedActor("art-coffee")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := results[0].Ts
+
+	// An earlier event claiming the same handle makes the accepted one invalid on replay.
+	early := seedActor("art-coffee")
+	early.Ts = 1
+
+	data, err := protolib.Marshal(early)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.db.Exec(`INSERT INTO events (ts, source_actor_id, data, status) VALUES (1, 3, ?, ?)`, data, EventRecordStatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = InsertAndCheckEvents(store, validation, -1, 1, nil, []*proto.Event{seedActor("benoit")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The submitter only gets the results of its own events.
+	if len(results) != 1 || results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	stuttering, err := store.GetStutteringEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stuttering) != 1 || stuttering[0].Event.Ts != ts || stuttering[0].Reason == "" {
+		t.Fatalf("unexpected stuttering events %+v", stuttering)
+	}
+
+	err = validation.ResolveStutteringEvent(store, 0, ts, func(StutteringEventRecord, Capability) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := storedStatuses(t, store)[ts]; status != EventRecordStatusResolved {
+		t.Fatalf("resolved event has status %v", status)
+	}
+
+	// The resolution holds even once the event would be valid again.
+	err = store.DeleteEvents([]int64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Run(store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := storedStatuses(t, store)[ts]; status != EventRecordStatusResolved {
+		t.Fatalf("resolved event has status %v after replay", status)
+	}
+
+	err = validation.ResolveStutteringEvent(store, 0, ts, func(StutteringEventRecord, Capability) error { return nil })
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("resolving a resolved event: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func edition(name string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_Edition{Edition: &proto.EventEdition{Name: name}}}
+}
+
+func TestFetchEventsScopesEditions(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+	projections := NewProjections()
+
+	insert := func(sourceActorID int64, events ...*proto.Event) []RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, events)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results
+	}
+
+	insert(2, seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art"))
+	insert(0, &proto.Event{Msg: &proto.Event_Permission{Permission: &proto.EventPermission{ActorId: 1, Permission: PermissionOrga}}})
+	insert(2, playerCharacter("player:coffee-art", "character:2024"))
+	insert(0, edition("2025"))
+	insert(2, playerCharacter("player:coffee-art", "character:2025"))
+
+	if results := insert(2, edition("2026")); results[0].Status != EventRecordStatusRejected {
+		t.Fatalf("edition opened by a player: %+v", results[0])
+	}
+
+	if results := insert(0, edition("2025")); results[0].Status != EventRecordStatusRejected {
+		t.Fatalf("edition opened twice: %+v", results[0])
+	}
+
+	characters := func(events []*proto.Event) []string {
+		var ids []string
+
+		for _, event := range events {
+			if v, ok := event.Msg.(*proto.Event_PlayerCharacter); ok {
+				ids = append(ids, v.PlayerCharacter.CharacterId)
+			}
+		}
+
+		return ids
+	}
+
+	for _, viewer := range []struct {
+		actorID int64
+		space   ActorSpace
+	}{{1, ActorSpaceOrga}, {2, ActorSpacePlayer}} {
+		events, err := FetchEvents(store, projections, viewer.actorID, viewer.space, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff([]string{"character:2025"}, characters(events)); diff != "" {
+			t.Fatalf("actor %d current edition (-want +got):\n%s", viewer.actorID, diff)
+		}
+
+		if _, ok := events[len(events)-2].Msg.(*proto.Event_Edition); !ok {
+			t.Fatalf("actor %d current edition is not opened by its edition event: %v", viewer.actorID, events)
+		}
+	}
+
+	events, err := FetchEvents(store, projections, 1, ActorSpaceOrga, "", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := events[1].Msg.(*proto.Event_SeedPlayer); !ok {
+		t.Fatalf("players of previous editions are not carried over: %v", events)
+	}
+
+	previous := events[1].Ts
+
+	events, err = FetchEvents(store, projections, 1, ActorSpaceOrga, "2025", previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"character:2025"}, characters(events)); diff != "" {
+		t.Fatalf("named edition (-want +got):\n%s", diff)
+	}
+
+	events, err = FetchEvents(store, projections, 1, ActorSpaceOrga, EditionInitial, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"character:2024"}, characters(events)); diff != "" {
+		t.Fatalf("initial edition (-want +got):\n%s", diff)
+	}
+
+	for _, event := range events {
+		if _, ok := EditionName(event); ok {
+			t.Fatalf("initial edition holds edition event %v", event)
+		}
+	}
+
+	_, err = FetchEvents(store, projections, 1, ActorSpaceOrga, "2024", -1)
+	if !errors.Is(err, ErrEditionNotFound) {
+		t.Fatalf("got %v, want ErrEditionNotFound", err)
+	}
+
+	// A fetch after any event of an edition returns the rest of it.
+	for _, edition := range []string{"", "2025", EditionInitial} {
+		all, err := FetchEvents(store, projections, 1, ActorSpaceOrga, edition, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, event := range all {
+			events, err := FetchEvents(store, projections, 1, ActorSpaceOrga, edition, event.Ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := all[i+1:]
+			if len(want) == 0 {
+				want = nil
+			}
+
+			if diff := cmp.Diff(want, events, protocmp.Transform()); diff != "" {
+				t.Fatalf("edition %q after %d (-want +got):\n%s", edition, event.Ts, diff)
+			}
+		}
+	}
+}
+
+func TestInsertAndCheckEventsReportsConflicts(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	edit := func(expectedVersion int64) RunEventResult {
+		t.Helper()
+
+		event := playerCharacter("player:coffee-art", "character:1")
+		event.GetPlayerCharacter().ExpectedVersion = expectedVersion
+
+		results, err := InsertAndCheckEvents(store, validation, -1, 2, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	_, err := InsertAndCheckEvents(store, validation, -1, 2, nil, []*proto.Event{
+		seedActor("art-coffee"),
+		seedPlayer("art-coffee", "player:coffee-art"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := edit(0)
+	if created.Status != EventRecordStatusAccepted {
+		t.Fatalf("creation: %+v", created)
+	}
+
+	first := edit(created.Ts)
+	if first.Status != EventRecordStatusAccepted {
+		t.Fatalf("first edit: %+v", first)
+	}
+
+	stale := edit(created.Ts)
+	if stale.Status != EventRecordStatusRejected || !stale.Conflict {
+		t.Fatalf("stale edit: %+v", stale)
+	}
+
+	if results, err := Run(store, map[int64]bool{stale.Ts: true}); err != nil || !results[0].Conflict {
+		t.Fatalf("replayed stale edit: %+v, %v", results, err)
+	}
+
+	if second := edit(first.Ts); second.Status != EventRecordStatusAccepted {
+		t.Fatalf("second edit: %+v", second)
+	}
+}
+
+func orgaEdit(characterID string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_PlayerCharacterOrgaEdit{PlayerCharacterOrgaEdit: &proto.EventPlayerCharacterOrgaEdit{
+		CharacterId:  characterID,
+		PublicResume: "resume",
+		Background:   "background",
+		MentalCrisis: "crisis",
+		Gitfs:        []*proto.Gift{{Title: "gift"}},
+		Handicaps:    []*proto.Handicap{{Title: "handicap"}},
+		Quests:       []*proto.Quest{{Title: "quest"}},
+		Tags:         []string{"tag"},
+	}}}
+}
+
+func TestFetchEventsOrgaEdit(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+	projections := NewProjections()
+
+	insert := func(sourceActorID int64, events ...*proto.Event) []RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, events)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results
+	}
+
+	insert(0, &proto.Event{Msg: &proto.Event_Permission{Permission: &proto.EventPermission{ActorId: 1, Permission: PermissionOrga}}})
+	insert(1, seedActor("benoit"))
+	insert(2, seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art"), playerCharacter("player:coffee-art", "character:1"))
+	insert(3, seedActor("tea-grumpy"))
+
+	for _, rejected := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+	}{
+		{2, orgaEdit("character:1")},
+		{3, orgaEdit("character:1")},
+		{1, orgaEdit("character:unknown")},
+	} {
+		if results := insert(rejected.sourceActorID, rejected.event); results[0].Status != EventRecordStatusRejected {
+			t.Errorf("orga edit of %d: %+v", rejected.sourceActorID, results[0])
+		}
+	}
+
+	results := insert(1, orgaEdit("character:1"))
+	if results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("orga edit: %+v", results[0])
+	}
+
+	fetchEdits := func(actorID int64, space ActorSpace) []*proto.Event {
+		t.Helper()
+
+		events, err := FetchEvents(store, projections, actorID, space, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var edits []*proto.Event
+
+		for _, event := range events {
+			if event.GetPlayerCharacterOrgaEdit() != nil {
+				edits = append(edits, event)
+			}
+		}
+
+		return edits
+	}
+
+	want := orgaEdit("character:1")
+	want.Ts = results[0].Ts
+
+	if diff := cmp.Diff([]*proto.Event{want}, fetchEdits(1, ActorSpaceOrga), protocmp.Transform()); diff != "" {
+		t.Errorf("orga edits (-want +got):\n%s", diff)
+	}
+
+	want = orgaEdit("character:1")
+	want.Ts = results[0].Ts
+	want.GetPlayerCharacterOrgaEdit().Background = ""
+	want.GetPlayerCharacterOrgaEdit().MentalCrisis = ""
+	want.GetPlayerCharacterOrgaEdit().Tags = nil
+
+	if diff := cmp.Diff([]*proto.Event{want}, fetchEdits(2, ActorSpacePlayer), protocmp.Transform()); diff != "" {
+		t.Errorf("player edits (-want +got):\n%s", diff)
+	}
+
+	if edits := fetchEdits(3, ActorSpacePlayer); len(edits) != 0 {
+		t.Errorf("other player got %d orga edits", len(edits))
+	}
+}
+
+func TestValidationReadsWithoutTheLock(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	results, err := InsertAndCheckEvents(store, validation, -1, 2, nil, []*proto.Event{seedActor("art-coffee")})
+	if err != nil || results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("seed: %+v, %v", results, err)
+	}
+
+	// A writer holding the validation does not block the readers.
+	validation.mu.Lock()
+
+	done := make(chan error)
+
+	go func() {
+		_, err := validation.FindActorIDByHandle(store, "art-coffee")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read waited for the validation lock")
+	}
+
+	validation.mu.Unlock()
+
+	// The events of another process are seen by the next read.
+	deactivation := &proto.Event{Msg: &proto.Event_ActorActivation{ActorActivation: &proto.EventActorActivation{Handle: "art-coffee"}}}
+
+	results, err = InsertAndCheckEvents(store, NewValidation(), -1, 0, nil, []*proto.Event{deactivation})
+	if err != nil || results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("deactivation: %+v, %v", results, err)
+	}
+
+	actorID, err := validation.FindActorIDByHandle(store, "art-coffee")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deactivated, err := validation.ActorDeactivated(store, actorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !deactivated {
+		t.Error("deactivation of another process not seen")
+	}
+}
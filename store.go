@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+type ActorSpace string
+
+const (
+	ActorSpaceOrga   ActorSpace = "orga"
+	ActorSpacePlayer ActorSpace = "player"
+)
+
+type EventRecordStatus uint64
+
+const (
+	EventRecordStatusPending EventRecordStatus = 1 << iota
+	EventRecordStatusAccepted
+	EventRecordStatusRejected
+	// EventRecordStatusStuttering marks an accepted event that a later replay
+	// rejected. It is left out of the state until an orga resolves it.
+	EventRecordStatusStuttering
+	// EventRecordStatusResolved marks a stuttering event an orga settled as
+	// rejected. The replays leave it as it is.
+	EventRecordStatusResolved
+
+	EventRecordStatusAll = EventRecordStatusAccepted | EventRecordStatusRejected | EventRecordStatusPending | EventRecordStatusStuttering | EventRecordStatusResolved
+)
+
+var eventRecordStatusNames = map[EventRecordStatus]string{
+	EventRecordStatusPending:    "pending",
+	EventRecordStatusAccepted:   "accepted",
+	EventRecordStatusRejected:   "rejected",
+	EventRecordStatusStuttering: "stuttering",
+	EventRecordStatusResolved:   "resolved",
+}
+
+func (e EventRecordStatus) String() string {
+	if name, ok := eventRecordStatusNames[e]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("EventRecordStatus(%d)", uint64(e))
+}
+
+func ParseEventRecordStatus(name string) (EventRecordStatus, error) {
+	for status, statusName := range eventRecordStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+
+	return 0, fmt.Errorf("EventRecordStatus %q not supported", name)
+}
+
+func (e EventRecordStatus) MarshalJSON() ([]byte, error) {
+	name, ok := eventRecordStatusNames[e]
+	if !ok {
+		return nil, fmt.Errorf("EventRecordStatus %d not supported", e)
+	}
+
+	return []byte(`"` + name + `"`), nil
+}
+
+func (e *EventRecordStatus) UnmarshalJSON(data []byte) error {
+	var name string
+
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return err
+	}
+
+	*e, err = ParseEventRecordStatus(name)
+
+	return err
+}
+
+type EventRecord struct {
+	SourceActorID int64
+	Event         *proto.Event
+	Status        EventRecordStatus
+	// Data is the event as stored, Hash chains it to the previous event.
+	Data []byte
+	Hash []byte
+}
+
+type StutteringEventRecord struct {
+	EventRecord
+	Reason string
+}
+
+// Batch is a set of events as submitted by a client. Token is the ES256 JWT
+// the client signed with PublicKey, its payload_hash claim covers Payload
+// and its events_hash claim the events of Payload.
+type Batch struct {
+	PublicKey []byte
+	Token     string
+	Payload   []byte
+	// Digests are the digests of the events of Payload as signed, kept once
+	// an erasure redacts Payload. They are nil for the batches left intact.
+	Digests [][]byte
+	// IdempotencyKey is the idempotency key of the payload, if any.
+	IdempotencyKey string
+}
+
+// EventStore holds the event log.
+type EventStore interface {
+	// InsertEvents stores the events as pending, sets their ts and returns
+	// them. batch is nil for events not submitted by a client.
+	InsertEvents(sourceActorID int64, batch *Batch, events []*proto.Event) ([]int64, error)
+	// GetBatch returns the batch an event was submitted in and the index of
+	// the event in its payload. It returns sql.ErrNoRows when the event has
+	// no batch.
+	GetBatch(eventTs int64) (Batch, int, error)
+	// GetEvents returns the events after from matching statusMask, ordered by
+	// ts.
+	GetEvents(from int64, statusMask EventRecordStatus) ([]EventRecord, error)
+	// CountEvents counts the events up to and including to matching
+	// statusMask.
+	CountEvents(to int64, statusMask EventRecordStatus) (int, error)
+	// UpdateEventStatus sets the status of an event. The reason is kept only
+	// for stuttering events.
+	UpdateEventStatus(eventTs int64, status EventRecordStatus, reason string) error
+	GetStutteringEvents() ([]StutteringEventRecord, error)
+	// ResolveStutteringEvent settles a stuttering event as resolved. It
+	// returns sql.ErrNoRows when the event is not stuttering.
+	ResolveStutteringEvent(eventTs int64) error
+	// DeleteEvents removes events from the log and chains the following
+	// events again. It is meant for events rejected by the Atomic call that
+	// inserted them.
+	DeleteEvents(tss []int64) error
+	// RewriteEvents replaces the stored events having the ts of events,
+	// keeping their status and their hash, and appends a Rewrite of each to
+	// the chain. The rewritten events are replaced in the payload of their
+	// batch too, the batch keeps the digests of its events as signed.
+	RewriteEvents(events []*proto.Event) error
+	// GetRewrites returns the rewrites in the order they were appended.
+	GetRewrites() ([]Rewrite, error)
+	// GetHead returns the ts of the last event and the hash the next event
+	// chains to, the one VerifyChain returns. It returns 0 and nil when
+	// there is no event.
+	GetHead() (int64, []byte, error)
+	// GetIdempotentResults returns the results of the batch an actor
+	// submitted with key since the unix time since. It returns
+	// sql.ErrNoRows for unknown or older keys.
+	GetIdempotentResults(actorID int64, key string, since int64) ([]RunEventResult, error)
+	InsertIdempotentResults(actorID int64, key string, createdAt int64, results []RunEventResult) error
+	// DeleteIdempotencyKeys removes the keys created before the unix time
+	// before.
+	DeleteIdempotencyKeys(before int64) error
+	// Atomic runs fn with a store whose writes are committed together when
+	// fn returns nil and discarded otherwise. Atomic calls are serialized.
+	Atomic(fn func(store EventStore) error) error
+}
+
+// ActorStore holds the actors, their public keys and their auth keys.
+type ActorStore interface {
+	InsertActor(space ActorSpace) (int64, error)
+	// LinkState attaches a new public key to an existing actor.
+	LinkState(actorID int64, publicKey []byte) (ActorSpace, error)
+	InsertAuthKey(actorID int64) (string, error)
+	GetActorSpaceByActorID(actorID int64) (ActorSpace, error)
+	UseAuthKey(key string) (int64, error)
+	// GetState returns the actor owning the public key, creating a player
+	// actor for unknown keys.
+	GetState(publicKey []byte) (int64, ActorSpace, error)
+}
+
+type Store interface {
+	EventStore
+	ActorStore
+}
+
+// FindActorIDByHandle returns the actor currently holding handle.
+func (v *Validation) FindActorIDByHandle(store EventStore, handle string) (int64, error) {
+	actorID, exists := int64(-1), false
+
+	err := v.read(store, func(view *validationView) {
+		actorID, exists = view.handles[handle]
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	if !exists {
+		return -1, fmt.Errorf("handle not found for handle %q", handle)
+	}
+
+	return actorID, nil
+}
+
+// ActorDeactivated reports whether actorID was deactivated.
+func (v *Validation) ActorDeactivated(store EventStore, actorID int64) (bool, error) {
+	var deactivated bool
+
+	err := v.read(store, func(view *validationView) {
+		_, deactivated = view.deactivated[actorID]
+	})
+
+	return deactivated, err
+}
+
+// Rewrite records that the data of the event at Ts was replaced. Head is the
+// hash of the last event of the log when it was rewritten, the events
+// appended after chain to Hash instead.
+type Rewrite struct {
+	Ts       int64
+	DataHash []byte
+	Head     []byte
+	Hash     []byte
+}
+
+// RewriteHash chains the rewrite of the event at ts to previous, the hash of
+// the log when it is appended. dataHash is the SHA-256 of the new data.
+func RewriteHash(previous []byte, ts int64, dataHash []byte) []byte {
+	hash := sha256.New()
+
+	hash.Write(previous)
+	binary.Write(hash, binary.BigEndian, ts)
+	hash.Write(dataHash)
+
+	return hash.Sum(nil)
+}
+
+// EventHash chains an event to the hash of the event preceding it in the log.
+// The status is left out, it changes with replays.
+func EventHash(previous []byte, ts int64, sourceActorID int64, data []byte) []byte {
+	hash := sha256.New()
+
+	hash.Write(previous)
+	binary.Write(hash, binary.BigEndian, ts)
+	binary.Write(hash, binary.BigEndian, sourceActorID)
+	hash.Write(data)
+
+	return hash.Sum(nil)
+}
+
+// VerifyChain walks the whole log and returns an error naming the first event
+// whose hash does not chain to the previous one. A rewritten event keeps the
+// hash of its original data, its current data must be the one of its last
+// rewrite.
+func VerifyChain(store EventStore) ([]byte, error) {
+	records, err := store.GetEvents(-1, EventRecordStatusAll)
+	if err != nil {
+		return nil, fmt.Errorf("get events: %w", err)
+	}
+
+	rewrites, err := store.GetRewrites()
+	if err != nil {
+		return nil, fmt.Errorf("get rewrites: %w", err)
+	}
+
+	rewritten := map[int64][]byte{}
+
+	for _, rewrite := range rewrites {
+		rewritten[rewrite.Ts] = rewrite.DataHash
+	}
+
+	var previous []byte
+
+	for _, record := range records {
+		hash := EventHash(previous, record.Event.Ts, record.SourceActorID, record.Data)
+		if !bytes.Equal(hash, record.Hash) {
+			dataHash := sha256.Sum256(record.Data)
+			if !bytes.Equal(rewritten[record.Event.Ts], dataHash[:]) {
+				return nil, fmt.Errorf("broken link at event %d", record.Event.Ts)
+			}
+		}
+
+		previous = record.Hash
+
+		for len(rewrites) > 0 && bytes.Equal(rewrites[0].Head, record.Hash) {
+			rewrite := rewrites[0]
+
+			if rewrite.Ts > record.Event.Ts {
+				return nil, fmt.Errorf("rewrite of event %d precedes it", rewrite.Ts)
+			}
+
+			if !bytes.Equal(RewriteHash(previous, rewrite.Ts, rewrite.DataHash), rewrite.Hash) {
+				return nil, fmt.Errorf("broken link at the rewrite of event %d", rewrite.Ts)
+			}
+
+			previous = rewrite.Hash
+			rewrites = rewrites[1:]
+		}
+	}
+
+	if len(rewrites) > 0 {
+		return nil, fmt.Errorf("rewrite of event %d does not follow the log", rewrites[0].Ts)
+	}
+
+	return previous, nil
+}
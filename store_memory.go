@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ebenaum/thekeeper/proto"
+	protolib "google.golang.org/protobuf/proto"
+)
+
+type memoryEvent struct {
+	ts            int64
+	sourceActorID int64
+	data          []byte
+	status        EventRecordStatus
+	reason        string
+	hash          []byte
+	batch         *Batch
+	batchIndex    int
+}
+
+type memoryIdempotencyKey struct {
+	actorID int64
+	key     string
+}
+
+type memoryIdempotentResults struct {
+	createdAt int64
+	results   []RunEventResult
+}
+
+type memoryAuthKey struct {
+	actorID    int64
+	redeemedAt int64
+}
+
+// MemoryStore is an EventStore and ActorStore kept in memory. It follows the
+// constraints of the SQLite schema and is meant for tests and ephemeral
+// servers.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	atomic     sync.Mutex
+	clock      *Clock
+	actors     map[int64]ActorSpace
+	lastActor  int64
+	publicKeys map[string]int64
+	authKeys   map[string]memoryAuthKey
+	events     []memoryEvent
+	rewrites   []Rewrite
+	idempotent map[memoryIdempotencyKey]memoryIdempotentResults
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clock: NewClock(),
+		actors: map[int64]ActorSpace{
+			0: ActorSpacePlayer,
+		},
+		publicKeys: map[string]int64{},
+		authKeys:   map[string]memoryAuthKey{},
+		idempotent: map[memoryIdempotencyKey]memoryIdempotentResults{},
+	}
+}
+
+func (m *MemoryStore) InsertActor(space ActorSpace) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if space != ActorSpaceOrga && space != ActorSpacePlayer {
+		return -1, fmt.Errorf("invalid actor space %q", space)
+	}
+
+	m.lastActor++
+	m.actors[m.lastActor] = space
+
+	return m.lastActor, nil
+}
+
+func (m *MemoryStore) LinkState(actorID int64, publicKey []byte) (ActorSpace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	space, exists := m.actors[actorID]
+	if !exists {
+		return "", fmt.Errorf("query: %w", sql.ErrNoRows)
+	}
+
+	err := m.linkPublicKey(actorID, publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	return space, nil
+}
+
+func (m *MemoryStore) linkPublicKey(actorID int64, publicKey []byte) error {
+	if actorID == 0 {
+		return fmt.Errorf("insert actors_public_keys: actor 0 cannot hold public keys")
+	}
+
+	if _, exists := m.publicKeys[string(publicKey)]; exists {
+		return fmt.Errorf("insert public key: public key already exists")
+	}
+
+	m.publicKeys[string(publicKey)] = actorID
+
+	return nil
+}
+
+func (m *MemoryStore) InsertAuthKey(actorID int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.actors[actorID]; !exists || actorID == 0 {
+		return "", fmt.Errorf("exec: invalid actor %d", actorID)
+	}
+
+	key := cryptorand.Text()
+
+	m.authKeys[key] = memoryAuthKey{actorID: actorID}
+
+	return key, nil
+}
+
+func (m *MemoryStore) GetActorSpaceByActorID(actorID int64) (ActorSpace, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	space, exists := m.actors[actorID]
+	if !exists {
+		return "", sql.ErrNoRows
+	}
+
+	return space, nil
+}
+
+func (m *MemoryStore) UseAuthKey(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	authKey, exists := m.authKeys[key]
+	if !exists {
+		return -1, fmt.Errorf("query: %w", sql.ErrNoRows)
+	}
+
+	authKey.redeemedAt = time.Now().UTC().Unix()
+	m.authKeys[key] = authKey
+
+	return authKey.actorID, nil
+}
+
+func (m *MemoryStore) GetState(publicKey []byte) (int64, ActorSpace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, exists := m.publicKeys[string(publicKey)]; exists {
+		return id, m.actors[id], nil
+	}
+
+	m.lastActor++
+	m.actors[m.lastActor] = ActorSpacePlayer
+
+	err := m.linkPublicKey(m.lastActor, publicKey)
+	if err != nil {
+		return -1, "", err
+	}
+
+	return m.lastActor, ActorSpacePlayer, nil
+}
+
+func (m *MemoryStore) InsertEvents(sourceActorID int64, batch *Batch, events []*proto.Event) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.actors[sourceActorID]; !exists {
+		return nil, fmt.Errorf("exec: unknown source actor %d", sourceActorID)
+	}
+
+	if batch != nil {
+		if _, exists := m.publicKeys[string(batch.PublicKey)]; !exists {
+			return nil, fmt.Errorf("insert batch: %w", sql.ErrNoRows)
+		}
+	}
+
+	if len(m.events) > 0 {
+		m.clock.Observe(m.events[len(m.events)-1].ts)
+	}
+
+	previous := m.chained(len(m.events))
+
+	ids := m.clock.Next(len(events))
+	records := make([]memoryEvent, len(events))
+
+	for i, event := range events {
+		event.Ts = ids[i]
+
+		data, err := protolib.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling event to proto: %w", err)
+		}
+
+		previous = EventHash(previous, ids[i], sourceActorID, data)
+
+		records[i] = memoryEvent{
+			ts:            ids[i],
+			sourceActorID: sourceActorID,
+			data:          data,
+			status:        EventRecordStatusPending,
+			hash:          previous,
+			batch:         batch,
+			batchIndex:    i,
+		}
+	}
+
+	m.events = append(m.events, records...)
+
+	return ids, nil
+}
+
+// search returns the index of the first event with a ts not lower than ts.
+func (m *MemoryStore) search(ts int64) int {
+	return sort.Search(len(m.events), func(i int) bool {
+		return m.events[i].ts >= ts
+	})
+}
+
+func (m *MemoryStore) record(event memoryEvent) (EventRecord, error) {
+	record := EventRecord{
+		SourceActorID: event.sourceActorID,
+		Event:         &proto.Event{},
+		Status:        event.status,
+		Data:          event.data,
+		Hash:          event.hash,
+	}
+
+	err := protolib.Unmarshal(event.data, record.Event)
+	if err != nil {
+		return record, fmt.Errorf("proto unmarshall: %w", err)
+	}
+
+	return record, nil
+}
+
+func (m *MemoryStore) GetBatch(eventTs int64) (Batch, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.search(eventTs)
+	if i == len(m.events) || m.events[i].ts != eventTs || m.events[i].batch == nil {
+		return Batch{}, -1, fmt.Errorf("query: %w", sql.ErrNoRows)
+	}
+
+	return *m.events[i].batch, m.events[i].batchIndex, nil
+}
+
+func (m *MemoryStore) GetEvents(from int64, statusMask EventRecordStatus) ([]EventRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []EventRecord
+
+	for _, event := range m.events[m.search(from+1):] {
+		if event.status&statusMask == 0 {
+			continue
+		}
+
+		record, err := m.record(event)
+		if err != nil {
+			return records, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (m *MemoryStore) CountEvents(to int64, statusMask EventRecordStatus) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int
+
+	for _, event := range m.events[:m.search(to+1)] {
+		if event.status&statusMask != 0 {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *MemoryStore) UpdateEventStatus(eventTs int64, status EventRecordStatus, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.search(eventTs)
+	if i == len(m.events) || m.events[i].ts != eventTs {
+		return sql.ErrNoRows
+	}
+
+	m.events[i].status = status
+	m.events[i].reason = ""
+
+	if status == EventRecordStatusStuttering {
+		m.events[i].reason = reason
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetStutteringEvents() ([]StutteringEventRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []StutteringEventRecord
+
+	for _, event := range m.events {
+		if event.status != EventRecordStatusStuttering {
+			continue
+		}
+
+		record, err := m.record(event)
+		if err != nil {
+			return records, err
+		}
+
+		records = append(records, StutteringEventRecord{record, event.reason})
+	}
+
+	return records, nil
+}
+
+func (m *MemoryStore) DeleteEvents(tss []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := len(m.events)
+
+	for _, ts := range tss {
+		i := m.search(ts)
+		if i == len(m.events) || m.events[i].ts != ts {
+			return sql.ErrNoRows
+		}
+
+		m.events = slices.Delete(m.events, i, i+1)
+
+		from = min(from, i)
+	}
+
+	for i := from; i < len(m.events); i++ {
+		m.events[i].hash = EventHash(m.chained(i), m.events[i].ts, m.events[i].sourceActorID, m.events[i].data)
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetHead() (int64, []byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.events) == 0 {
+		return 0, nil, nil
+	}
+
+	return m.events[len(m.events)-1].ts, m.chained(len(m.events)), nil
+}
+
+// chained returns the hash the event at index i chains to: the hash of the
+// last rewrite appended after the previous event, or the hash of that event.
+func (m *MemoryStore) chained(i int) []byte {
+	if i == 0 {
+		return nil
+	}
+
+	head := m.events[i-1].hash
+
+	for j := len(m.rewrites) - 1; j >= 0; j-- {
+		if bytes.Equal(m.rewrites[j].Head, head) {
+			return m.rewrites[j].Hash
+		}
+	}
+
+	return head
+}
+
+func (m *MemoryStore) RewriteEvents(events []*proto.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if len(m.events) == 0 {
+		return sql.ErrNoRows
+	}
+
+	head := m.events[len(m.events)-1].hash
+	previous := m.chained(len(m.events))
+
+	for _, event := range events {
+		i := m.search(event.Ts)
+		if i == len(m.events) || m.events[i].ts != event.Ts {
+			return sql.ErrNoRows
+		}
+
+		data, err := protolib.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshalling event to proto: %w", err)
+		}
+
+		m.events[i].data = data
+
+		// The batch is replaced rather than edited, Atomic snapshots share
+		// it.
+		if batch := m.events[i].batch; batch != nil {
+			redacted := *batch
+
+			if redacted.Digests == nil {
+				redacted.Digests, err = EventDigests(batch.Payload)
+				if err != nil {
+					return fmt.Errorf("redact batch: %w", err)
+				}
+			}
+
+			redacted.Payload, err = redactPayload(batch.Payload, m.events[i].batchIndex, event)
+			if err != nil {
+				return fmt.Errorf("redact batch: %w", err)
+			}
+
+			for j := range m.events {
+				if m.events[j].batch == batch {
+					m.events[j].batch = &redacted
+				}
+			}
+		}
+
+		dataHash := sha256.Sum256(data)
+		previous = RewriteHash(previous, event.Ts, dataHash[:])
+
+		m.rewrites = append(m.rewrites, Rewrite{event.Ts, dataHash[:], head, previous})
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetRewrites() ([]Rewrite, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.rewrites), nil
+}
+
+func (m *MemoryStore) GetIdempotentResults(actorID int64, key string, since int64) ([]RunEventResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	idempotent, exists := m.idempotent[memoryIdempotencyKey{actorID, key}]
+	if !exists || idempotent.createdAt < since {
+		return nil, fmt.Errorf("query: %w", sql.ErrNoRows)
+	}
+
+	return slices.Clone(idempotent.results), nil
+}
+
+func (m *MemoryStore) InsertIdempotentResults(actorID int64, key string, createdAt int64, results []RunEventResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.actors[actorID]; !exists {
+		return fmt.Errorf("exec: unknown actor %d", actorID)
+	}
+
+	m.idempotent[memoryIdempotencyKey{actorID, key}] = memoryIdempotentResults{createdAt, slices.Clone(results)}
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteIdempotencyKeys(before int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	maps.DeleteFunc(m.idempotent, func(_ memoryIdempotencyKey, idempotent memoryIdempotentResults) bool {
+		return idempotent.createdAt < before
+	})
+
+	return nil
+}
+
+// memoryAtomicStore is the store handed to the fn of MemoryStore.Atomic,
+// nested Atomic calls run within the outer one.
+type memoryAtomicStore struct {
+	*MemoryStore
+}
+
+func (m memoryAtomicStore) Atomic(fn func(store EventStore) error) error {
+	return fn(m)
+}
+
+// Atomic restores the event log and the idempotency keys as they were before
+// fn when fn fails.
+func (m *MemoryStore) Atomic(fn func(store EventStore) error) error {
+	m.atomic.Lock()
+	defer m.atomic.Unlock()
+
+	m.mu.RLock()
+	events := slices.Clone(m.events)
+	rewrites := slices.Clone(m.rewrites)
+	idempotent := maps.Clone(m.idempotent)
+	m.mu.RUnlock()
+
+	err := fn(memoryAtomicStore{m})
+	if err != nil {
+		m.mu.Lock()
+		m.events = events
+		m.rewrites = rewrites
+		m.idempotent = idempotent
+		m.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) ResolveStutteringEvent(eventTs int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.search(eventTs)
+	if i == len(m.events) || m.events[i].ts != eventTs {
+		return fmt.Errorf("query: %w", sql.ErrNoRows)
+	}
+
+	if m.events[i].status != EventRecordStatusStuttering {
+		return sql.ErrNoRows
+	}
+
+	m.events[i].status = EventRecordStatusResolved
+	m.events[i].reason = ""
+
+	return nil
+}
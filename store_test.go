@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+	"github.com/google/go-cmp/cmp"
+	protolib "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return openTestStore(t)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return NewMemoryStore()
+	})
+}
+
+// testStore is the conformance suite every Store implementation must pass.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("actors", func(t *testing.T) {
+		store := newStore(t)
+
+		orgaID, err := store.InsertActor(ActorSpaceOrga)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		space, err := store.GetActorSpaceByActorID(orgaID)
+		if err != nil || space != ActorSpaceOrga {
+			t.Fatalf("got space %q, %v", space, err)
+		}
+
+		_, err = store.GetActorSpaceByActorID(orgaID + 100)
+		if err == nil {
+			t.Fatal("expected an error for an unknown actor")
+		}
+
+		playerID, space, err := store.GetState([]byte("player-key"))
+		if err != nil || space != ActorSpacePlayer || playerID == orgaID || playerID == 0 {
+			t.Fatalf("got actor %d space %q, %v", playerID, space, err)
+		}
+
+		id, space, err := store.GetState([]byte("player-key"))
+		if err != nil || id != playerID || space != ActorSpacePlayer {
+			t.Fatalf("known key: got actor %d space %q, %v", id, space, err)
+		}
+
+		space, err = store.LinkState(orgaID, []byte("orga-key"))
+		if err != nil || space != ActorSpaceOrga {
+			t.Fatalf("link: got space %q, %v", space, err)
+		}
+
+		id, space, err = store.GetState([]byte("orga-key"))
+		if err != nil || id != orgaID || space != ActorSpaceOrga {
+			t.Fatalf("linked key: got actor %d space %q, %v", id, space, err)
+		}
+
+		_, err = store.LinkState(playerID, []byte("orga-key"))
+		if err == nil {
+			t.Fatal("expected an error linking a key twice")
+		}
+
+		_, err = store.LinkState(orgaID+100, []byte("other-key"))
+		if err == nil {
+			t.Fatal("expected an error linking a key to an unknown actor")
+		}
+	})
+
+	t.Run("auth keys", func(t *testing.T) {
+		store := newStore(t)
+
+		actorID, err := store.InsertActor(ActorSpacePlayer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, err := store.InsertAuthKey(actorID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, err := store.UseAuthKey(key)
+		if err != nil || id != actorID {
+			t.Fatalf("got actor %d, %v", id, err)
+		}
+
+		_, err = store.UseAuthKey("unknown")
+		if err == nil {
+			t.Fatal("expected an error for an unknown key")
+		}
+
+		_, err = store.InsertAuthKey(0)
+		if err == nil {
+			t.Fatal("expected an error for actor 0")
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		store := newStore(t)
+
+		actorID, err := store.InsertActor(ActorSpaceOrga)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events := []*proto.Event{seedActor("benoit"), seedActor("art-coffee"), seedActor("tea-grumpy")}
+
+		tss, err := store.InsertEvents(actorID, nil, events)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, ts := range tss {
+			if events[i].Ts != ts || (i > 0 && ts <= tss[i-1]) {
+				t.Fatalf("unexpected ts %v", tss)
+			}
+		}
+
+		_, err = store.InsertEvents(actorID+100, nil, []*proto.Event{seedActor("nobody")})
+		if err == nil {
+			t.Fatal("expected an error for an unknown source actor")
+		}
+
+		_, err = VerifyChain(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := store.GetEvents(-1, EventRecordStatusAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(records) != 3 {
+			t.Fatalf("got %d events, want 3", len(records))
+		}
+
+		for i, record := range records {
+			if record.SourceActorID != actorID || record.Status != EventRecordStatusPending {
+				t.Fatalf("unexpected record %+v", record)
+			}
+
+			if diff := cmp.Diff(events[i], record.Event, protocmp.Transform()); diff != "" {
+				t.Fatalf("event #%d differs:\n%s", i, diff)
+			}
+		}
+
+		err = store.UpdateEventStatus(tss[0], EventRecordStatusAccepted, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = store.UpdateEventStatus(tss[1], EventRecordStatusStuttering, "handle taken")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = store.UpdateEventStatus(-5, EventRecordStatusAccepted, "")
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("got %v, want sql.ErrNoRows", err)
+		}
+
+		records, err = store.GetEvents(tss[0], EventRecordStatusPending|EventRecordStatusStuttering)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(records) != 2 || records[0].Event.Ts != tss[1] || records[1].Event.Ts != tss[2] {
+			t.Fatalf("unexpected records %+v", records)
+		}
+
+		count, err := store.CountEvents(tss[1], EventRecordStatusAll)
+		if err != nil || count != 2 {
+			t.Fatalf("got count %d, %v", count, err)
+		}
+
+		count, err = store.CountEvents(tss[2], EventRecordStatusAccepted)
+		if err != nil || count != 1 {
+			t.Fatalf("got accepted count %d, %v", count, err)
+		}
+
+		stuttering, err := store.GetStutteringEvents()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stuttering) != 1 || stuttering[0].Event.Ts != tss[1] || stuttering[0].Reason != "handle taken" {
+			t.Fatalf("unexpected stuttering events %+v", stuttering)
+		}
+
+		err = store.ResolveStutteringEvent(tss[0])
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("resolving an accepted event: got %v, want sql.ErrNoRows", err)
+		}
+
+		err = store.ResolveStutteringEvent(tss[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stuttering, err = store.GetStutteringEvents()
+		if err != nil || len(stuttering) != 0 {
+			t.Fatalf("got %d stuttering events, %v", len(stuttering), err)
+		}
+
+		_, err = VerifyChain(store)
+		if err != nil {
+			t.Fatalf("status changes broke the chain: %v", err)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		store := newStore(t)
+		validation := NewValidation()
+
+		orgaID, err := store.InsertActor(ActorSpaceOrga)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := InsertAndCheckEvents(store, validation, -1, orgaID, nil, []*proto.Event{seedActor("benoit"), seedActor("again")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(results) != 2 || results[0].Status != EventRecordStatusAccepted || results[1].Status != EventRecordStatusRejected {
+			t.Fatalf("unexpected results %+v", results)
+		}
+
+		events, err := FetchEvents(store, NewProjections(), orgaID, ActorSpaceOrga, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 1 || events[0].GetSeedActor().GetHandle() != "benoit" {
+			t.Fatalf("unexpected events %v", events)
+		}
+	})
+}
+
+func TestVerifyChainSQLite(t *testing.T) {
+	store := newTestStore(t)
+
+	tss, err := store.InsertEvents(1, nil, []*proto.Event{seedActor("benoit"), seedActor("art-coffee")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Events stored before the chain existed get chained by the next insert.
+	_, err = store.db.Exec(`UPDATE events SET hash = NULL`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyChain(store)
+	if err == nil {
+		t.Fatal("expected unchained events to be reported")
+	}
+
+	more, err := store.InsertEvents(2, nil, []*proto.Event{seedActor("tea-grumpy")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := VerifyChain(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.GetEvents(tss[1], EventRecordStatusAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Event.Ts != more[0] || !bytes.Equal(records[0].Hash, head) {
+		t.Fatalf("unexpected head %x for records %+v", head, records)
+	}
+
+	_, err = store.db.Exec(`UPDATE events SET source_actor_id = 3 WHERE ts = ?`, tss[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyChain(store)
+	if err == nil || !strings.Contains(err.Error(), strconv.FormatInt(tss[1], 10)) {
+		t.Fatalf("got %v, want a broken link at event %d", err, tss[1])
+	}
+
+	_, err = store.db.Exec(`UPDATE events SET source_actor_id = 1 WHERE ts = ?`, tss[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A rewrite keeps the hash of the event and is appended to the chain.
+	rewritten := seedActor("tea")
+	rewritten.Ts = tss[0]
+
+	err = store.RewriteEvents([]*proto.Event{rewritten})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewriteHead, err := VerifyChain(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(rewriteHead, head) {
+		t.Fatal("the rewrite is not part of the head")
+	}
+
+	tampered := seedActor("coffee")
+	tampered.Ts = tss[0]
+
+	data, err := protolib.Marshal(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.db.Exec(`UPDATE events SET data = ? WHERE ts = ?`, data, tss[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyChain(store)
+	if err == nil || !strings.Contains(err.Error(), strconv.FormatInt(tss[0], 10)) {
+		t.Fatalf("got %v, want a broken link at event %d", err, tss[0])
+	}
+}
@@ -40,3 +40,10 @@ CREATE TABLE IF NOT EXISTS events (
 
   FOREIGN KEY(source_actor_id) REFERENCES actors(id)
 ) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS events_stuttering (
+  ts INTEGER PRIMARY KEY,
+  reason TEXT NOT NULL,
+
+  FOREIGN KEY(ts) REFERENCES events(ts)
+);
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+func transfer(characterID, playerID string) *proto.Event {
+	return &proto.Event{Msg: &proto.Event_CharacterTransfer{CharacterTransfer: &proto.EventCharacterTransfer{CharacterId: characterID, PlayerId: playerID}}}
+}
+
+func TestCharacterTransfer(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+	projections := NewProjections()
+
+	insert := func(sourceActorID int64, event *proto.Event) RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, []*proto.Event{event})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results[0]
+	}
+
+	fetch := func(actorID int64, projections *Projections) []*proto.Event {
+		t.Helper()
+
+		events, err := FetchEvents(store, projections, actorID, ActorSpacePlayer, "", -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return events
+	}
+
+	insert(2, seedActor("art-coffee"))
+	insert(2, seedPlayer("art-coffee", "player:coffee-art"))
+	insert(2, playerCharacter("player:coffee-art", "character:1"))
+	insert(3, seedActor("friend"))
+	insert(3, seedPlayer("friend", "player:friend"))
+	insert(0, permission(1, PermissionOrga, false))
+	insert(1, orgaEdit("character:1"))
+	insert(2, review("character:1", ReviewSubmitted, ""))
+
+	// Snapshots built before the transfer must follow it.
+	fetch(2, projections)
+	fetch(3, projections)
+
+	for i, test := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+		accepted      bool
+	}{
+		{2, transfer("character:1", "player:friend"), false},
+		{1, transfer("character:1", "player:coffee-art"), false},
+		{1, transfer("character:1", "player:unknown"), false},
+		{1, transfer("character:2", "player:friend"), false},
+		{1, transfer("character:1", "player:friend"), true},
+		{2, playerCharacter("player:coffee-art", "character:1"), false},
+		{3, playerCharacter("player:friend", "character:1"), true},
+	} {
+		result := insert(test.sourceActorID, test.event)
+		if (result.Status == EventRecordStatusAccepted) != test.accepted {
+			t.Errorf("#%d: accepted %v, got %+v", i, test.accepted, result)
+		}
+	}
+
+	for _, projections := range []*Projections{projections, NewProjections()} {
+		for _, event := range fetch(2, projections) {
+			if characterID(event) == "character:1" {
+				t.Errorf("previous player still gets %v", event)
+			}
+		}
+
+		events := fetch(3, projections)
+
+		var saves, edits, reviews int
+
+		for _, event := range events {
+			switch v := event.Msg.(type) {
+			case *proto.Event_PlayerCharacter:
+				saves++
+
+				if v.PlayerCharacter.PlayerId != "player:friend" {
+					t.Errorf("save of player %q", v.PlayerCharacter.PlayerId)
+				}
+			case *proto.Event_PlayerCharacterOrgaEdit:
+				edits++
+			case *proto.Event_CharacterReview:
+				reviews++
+			}
+		}
+
+		if saves != 2 || edits != 1 || reviews != 1 {
+			t.Errorf("new player gets %d saves, %d orga edits, %d reviews", saves, edits, reviews)
+		}
+
+		if !slices.IsSortedFunc(events, func(a, b *proto.Event) int { return cmp.Compare(a.Ts, b.Ts) }) {
+			t.Errorf("events out of order")
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+// UniversEntry is an entry of the univers JSON the app is built from.
+type UniversEntry struct {
+	Key         string   `json:"key"`
+	Tags        []string `json:"tags"`
+	Label       string   `json:"label"`
+	Description string   `json:"description"`
+}
+
+// UniversSkill is a skill with the cost of each of its ranks and the race or
+// vdv it requires, if any.
+type UniversSkill struct {
+	Costs       []int
+	RequireType string
+	RequireKey  string
+}
+
+// Univers holds the character creation rules of the univers JSON, the ones
+// public/app.js enforces while a character is edited.
+type Univers struct {
+	Races  map[string]struct{}
+	Vdvs   map[string]struct{}
+	Skills map[string]UniversSkill
+	// Items maps the inventory items to their cost.
+	Items map[string]int
+	// CharacteristicLevels maps the characteristics to their levels and the
+	// skill points each level gives.
+	CharacteristicLevels map[string]map[int]int
+	CharacteristicPoints int
+	SkillPoints          int
+}
+
+// tagValue returns the value of the first tag of tags with the prefix name:.
+func tagValue(tags []string, name string) (string, bool) {
+	for _, tag := range tags {
+		if value, ok := strings.CutPrefix(tag, name+":"); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func tagInt(entry UniversEntry, name string) (int, error) {
+	value, ok := tagValue(entry.Tags, name)
+	if !ok {
+		return 0, fmt.Errorf("entry %q: missing %s tag", entry.Key, name)
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("entry %q: %s tag: %w", entry.Key, name, err)
+	}
+
+	return n, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseUnivers reads the rules out of a univers JSON.
+func ParseUnivers(data []byte) (*Univers, error) {
+	var entries []UniversEntry
+
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	univers := &Univers{
+		Races:                map[string]struct{}{},
+		Vdvs:                 map[string]struct{}{},
+		Skills:               map[string]UniversSkill{},
+		Items:                map[string]int{},
+		CharacteristicLevels: map[string]map[int]int{},
+	}
+
+	skillLevels := map[string]map[int]int{}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Key == "characteristics-default-points":
+			univers.CharacteristicPoints, err = tagInt(entry, "n")
+		case entry.Key == "skills-default-points":
+			univers.SkillPoints, err = tagInt(entry, "n")
+		case hasTag(entry.Tags, "race"):
+			univers.Races[entry.Key] = struct{}{}
+		case hasTag(entry.Tags, "vdv"):
+			univers.Vdvs[entry.Key] = struct{}{}
+		case hasTag(entry.Tags, "inventory"):
+			univers.Items[entry.Key], err = tagInt(entry, "cost")
+		case hasTag(entry.Tags, "skill"):
+			skill := univers.Skills[entry.Key]
+
+			if require, ok := tagValue(entry.Tags, "require"); ok {
+				skill.RequireType, skill.RequireKey, _ = strings.Cut(require, ":")
+			}
+
+			univers.Skills[entry.Key] = skill
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if key, ok := tagValue(entry.Tags, "skill"); ok {
+			rank, err := tagInt(entry, "level")
+			if err != nil {
+				return nil, err
+			}
+
+			cost, err := tagInt(entry, "cost")
+			if err != nil {
+				return nil, err
+			}
+
+			if skillLevels[key] == nil {
+				skillLevels[key] = map[int]int{}
+			}
+
+			skillLevels[key][rank] = cost
+		}
+
+		if key, ok := tagValue(entry.Tags, "characteristic"); ok {
+			rank, err := tagInt(entry, "level")
+			if err != nil {
+				return nil, err
+			}
+
+			// Only the levels of savoir give skill points.
+			var points int
+
+			if _, ok := tagValue(entry.Tags, "pc"); ok {
+				points, err = tagInt(entry, "pc")
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if univers.CharacteristicLevels[key] == nil {
+				univers.CharacteristicLevels[key] = map[int]int{}
+			}
+
+			univers.CharacteristicLevels[key][rank] = points
+		}
+	}
+
+	for key, skill := range univers.Skills {
+		for rank := 1; rank <= len(skillLevels[key]); rank++ {
+			cost, ok := skillLevels[key][rank]
+			if !ok {
+				return nil, fmt.Errorf("skill %q: missing level %d", key, rank)
+			}
+
+			skill.Costs = append(skill.Costs, cost)
+		}
+
+		univers.Skills[key] = skill
+	}
+
+	return univers, nil
+}
+
+// inventoryBudget is the number of gems a character can spend on items, it
+// grows with dexterite.
+func inventoryBudget(dexterite int32) int {
+	return max(0, int(dexterite)+1)
+}
+
+// CheckCharacter rejects a character using unknown keys or exceeding the
+// characteristics, skills or inventory budgets.
+func (u *Univers) CheckCharacter(character *proto.EventPlayerCharacter) error {
+	if _, exists := u.Races[character.Race]; character.Race != "" && !exists {
+		return fmt.Errorf("univers: unknown race %q", character.Race)
+	}
+
+	if _, exists := u.Vdvs[character.Vdv]; character.Vdv != "" && !exists {
+		return fmt.Errorf("univers: unknown vdv %q", character.Vdv)
+	}
+
+	characteristics := map[string]int32{
+		"corps":     character.Characteristics.GetCorps(),
+		"dexterite": character.Characteristics.GetDexterite(),
+		"influence": character.Characteristics.GetInfluence(),
+		"savoir":    character.Characteristics.GetSavoir(),
+	}
+
+	var characteristicPoints int
+
+	for key, level := range characteristics {
+		levels, defined := u.CharacteristicLevels[key]
+		if _, exists := levels[int(level)]; defined && !exists {
+			return fmt.Errorf("univers: characteristic %q has no level %d", key, level)
+		}
+
+		characteristicPoints += int(level)
+	}
+
+	if characteristicPoints > u.CharacteristicPoints {
+		return fmt.Errorf("univers: characteristics use %d points out of %d", characteristicPoints, u.CharacteristicPoints)
+	}
+
+	skillBudget := u.SkillPoints + u.CharacteristicLevels["savoir"][int(characteristics["savoir"])]
+
+	var skillPoints int
+
+	for key, rank := range character.Skills {
+		skill, exists := u.Skills[key]
+		if !exists {
+			return fmt.Errorf("univers: unknown skill %q", key)
+		}
+
+		if rank < 1 || int(rank) > len(skill.Costs) {
+			return fmt.Errorf("univers: skill %q has no rank %d", key, rank)
+		}
+
+		switch skill.RequireType {
+		case "race":
+			if character.Race != skill.RequireKey {
+				return fmt.Errorf("univers: skill %q requires race %q", key, skill.RequireKey)
+			}
+		case "vdv":
+			if character.Vdv != skill.RequireKey {
+				return fmt.Errorf("univers: skill %q requires vdv %q", key, skill.RequireKey)
+			}
+		}
+
+		for _, cost := range skill.Costs[:rank] {
+			skillPoints += cost
+		}
+	}
+
+	if skillPoints > skillBudget {
+		return fmt.Errorf("univers: skills use %d points out of %d", skillPoints, skillBudget)
+	}
+
+	var gems int
+
+	for key, count := range character.Inventory {
+		cost, exists := u.Items[key]
+		if !exists {
+			return fmt.Errorf("univers: unknown item %q", key)
+		}
+
+		if count < 0 {
+			return fmt.Errorf("univers: item %q count %d", key, count)
+		}
+
+		gems += int(count) * cost
+	}
+
+	if budget := inventoryBudget(characteristics["dexterite"]); gems > budget {
+		return fmt.Errorf("univers: inventory uses %d gems out of %d", gems, budget)
+	}
+
+	return nil
+}
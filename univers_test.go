@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ebenaum/thekeeper/proto"
+)
+
+func testUnivers(t *testing.T) []byte {
+	t.Helper()
+
+	definition, err := os.ReadFile("testdata/univers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return definition
+}
+
+func TestUniversCheckCharacter(t *testing.T) {
+	univers, err := ParseUnivers(testUnivers(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, test := range map[string]struct {
+		character *proto.EventPlayerCharacter
+		valid     bool
+	}{
+		"empty": {&proto.EventPlayerCharacter{}, true},
+		"full budgets": {&proto.EventPlayerCharacter{
+			Race:            "elfe",
+			Vdv:             "mage",
+			Characteristics: &proto.Characteristics{Savoir: 1, Dexterite: 1},
+			Skills:          map[string]int32{"escrime": 2, "sorts": 1},
+			Inventory:       map[string]int32{"epee": 1},
+		}, true},
+		"characteristics over budget": {&proto.EventPlayerCharacter{
+			Characteristics: &proto.Characteristics{Savoir: 1, Dexterite: 1, Corps: 1},
+		}, false},
+		"unknown characteristic level": {&proto.EventPlayerCharacter{
+			Characteristics: &proto.Characteristics{Dexterite: 2},
+		}, false},
+		"unknown race": {&proto.EventPlayerCharacter{Race: "orc"}, false},
+		"unknown vdv":  {&proto.EventPlayerCharacter{Vdv: "voleur"}, false},
+		"unknown skill": {&proto.EventPlayerCharacter{
+			Skills: map[string]int32{"vol": 1},
+		}, false},
+		"unknown skill rank": {&proto.EventPlayerCharacter{
+			Skills: map[string]int32{"escrime": 3},
+		}, false},
+		"missing requirement": {&proto.EventPlayerCharacter{
+			Skills: map[string]int32{"sorts": 1},
+		}, false},
+		"skills over budget": {&proto.EventPlayerCharacter{
+			Vdv:    "mage",
+			Skills: map[string]int32{"escrime": 2, "sorts": 1},
+		}, false},
+		"unknown item": {&proto.EventPlayerCharacter{
+			Inventory: map[string]int32{"arc": 1},
+		}, false},
+		"inventory over budget": {&proto.EventPlayerCharacter{
+			Inventory: map[string]int32{"epee": 1},
+		}, false},
+	} {
+		err := univers.CheckCharacter(test.character)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: valid %v, got %v", name, test.valid, err)
+		}
+	}
+}
+
+func TestInsertAndCheckEventsEnforcesUnivers(t *testing.T) {
+	store := newTestStore(t)
+	validation := NewValidation()
+
+	insert := func(sourceActorID int64, events ...*proto.Event) []RunEventResult {
+		t.Helper()
+
+		results, err := InsertAndCheckEvents(store, validation, -1, sourceActorID, nil, events)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return results
+	}
+
+	character := func(characterID string, bypassRules bool) *proto.Event {
+		event := playerCharacter("player:coffee-art", characterID)
+		event.GetPlayerCharacter().Skills = map[string]int32{"vol": 1}
+		event.GetPlayerCharacter().BypassRules = bypassRules
+
+		return event
+	}
+
+	insert(0, &proto.Event{Msg: &proto.Event_Permission{Permission: &proto.EventPermission{ActorId: 1, Permission: PermissionOrga}}})
+	insert(2, seedActor("art-coffee"), seedPlayer("art-coffee", "player:coffee-art"))
+
+	if results := insert(2, character("character:before", false)); results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("character before the univers: %+v", results[0])
+	}
+
+	univers := &proto.Event{Msg: &proto.Event_Univers{Univers: &proto.EventUnivers{Definition: testUnivers(t)}}}
+
+	if results := insert(1, univers); results[0].Status != EventRecordStatusRejected {
+		t.Fatalf("univers from an orga: %+v", results[0])
+	}
+
+	if results := insert(0, univers); results[0].Status != EventRecordStatusAccepted {
+		t.Fatalf("univers: %+v", results[0])
+	}
+
+	for _, test := range []struct {
+		sourceActorID int64
+		event         *proto.Event
+		status        EventRecordStatus
+	}{
+		{2, character("character:invalid", false), EventRecordStatusRejected},
+		{2, character("character:bypass", true), EventRecordStatusRejected},
+		{1, character("character:invalid", false), EventRecordStatusRejected},
+		{1, character("character:orga", true), EventRecordStatusAccepted},
+		{2, playerCharacter("player:coffee-art", "character:valid"), EventRecordStatusAccepted},
+	} {
+		results := insert(test.sourceActorID, test.event)
+		if results[0].Status != test.status {
+			t.Errorf("%s by %d: %+v, want %v", test.event.GetPlayerCharacter().CharacterId, test.sourceActorID, results[0], test.status)
+		}
+	}
+
+	err := VerifyReplay(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+}